@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ktappdev/gitcomm/internal/analyzer"
+	"github.com/ktappdev/gitcomm/internal/changelog"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+)
+
+// runChangelog generates grouped release notes for a range of commits and
+// prints them or merges them into CHANGELOG.md.
+func runChangelog(args []string) error {
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	versionFlag := fs.String("version", "", "Release name for the heading (default: the tag at <to>, else Unreleased)")
+	writeFlag := fs.Bool("write", false, "Update the changelog file instead of printing the notes")
+	fileFlag := fs.String("file", "", "Changelog file to update with -write (default: CHANGELOG.md at the repository root)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: gitcomm changelog [-version <name>] [-write] [-file <path>] [<from>[..<to>]]")
+	}
+
+	// Without -write the notes are printed to stdout for piping, so
+	// progress goes to stderr.
+	var progress io.Writer = os.Stdout
+	if !*writeFlag {
+		progress = os.Stderr
+	}
+
+	base, head, label, err := resolveChangelogRange(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	commits, err := git.ListCommits(base, head)
+	if err != nil {
+		return err
+	}
+	entries := make([]analyzer.ChangelogCommit, 0, len(commits))
+	for _, commit := range commits {
+		// Merges only repeat the commits they bring in, which are already
+		// part of the range.
+		if len(commit.Parents) > 1 {
+			continue
+		}
+		paths, err := git.ChangedPaths(commit.Hash)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, analyzer.ChangelogCommit{Hash: commit.Hash, Message: commit.Message, Paths: paths})
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no commits in %s", label)
+	}
+	fmt.Fprintf(progress, "📜 Summarizing %d %s in %s\n", len(entries), git.Pluralize(len(entries), "commit", "commits"), label)
+
+	version, date, err := changelogVersion(*versionFlag, head)
+	if err != nil {
+		return err
+	}
+	notes, err := analyzer.GenerateReleaseNotes(entries, progress)
+	if err != nil {
+		return err
+	}
+	diag.Info("main", "generated release notes", "range", label, "commits", len(entries), "version", version)
+
+	if !*writeFlag {
+		_, err := fmt.Fprintf(os.Stdout, "%s\n\n%s\n", changelog.Heading(version, date), notes.Markdown())
+		return err
+	}
+
+	path := *fileFlag
+	if path == "" {
+		root, err := git.RepoRoot()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(root, changelog.FileName)
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	updated := changelog.Update(string(existing), version, date, notes)
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("✅ Updated %s with %s\n", path, strings.TrimPrefix(changelog.Heading(version, date), "## "))
+	return nil
+}
+
+// resolveChangelogRange turns the optional argument into base and head
+// commits. With no argument the range starts at the previous tag, or covers
+// all history when the repository has no tags; a bare ref means <ref>..HEAD.
+func resolveChangelogRange(arg string) (string, string, string, error) {
+	if strings.Contains(arg, "..") {
+		base, head, err := git.ResolveRange(arg)
+		return base, head, arg, err
+	}
+	head, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		return "", "", "", err
+	}
+	if arg != "" {
+		base, err := git.ResolveCommit(arg)
+		return base, head, arg + "..HEAD", err
+	}
+	tag := git.PreviousTag("HEAD")
+	if tag == "" {
+		return "", head, "all history", nil
+	}
+	base, err := git.ResolveCommit(tag)
+	return base, head, tag + "..HEAD", err
+}
+
+// changelogVersion picks the release heading: -version, then a tag at head,
+// then Unreleased. Tagged releases use the tag's commit date; a named but
+// untagged release uses today.
+func changelogVersion(flagValue, head string) (string, string, error) {
+	tag := git.TagAt(head)
+	version := flagValue
+	if version == "" {
+		version = tag
+	}
+	tagged := tag != "" && version == tag
+	if version == "" || strings.EqualFold(version, changelog.Unreleased) {
+		return changelog.Unreleased, "", nil
+	}
+	if !tagged {
+		return version, time.Now().Format("2006-01-02"), nil
+	}
+	date, err := git.CommitDate(head)
+	if err != nil {
+		return "", "", err
+	}
+	return version, date, nil
+}
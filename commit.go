@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+)
+
+// maxCommitOutputLines caps how much of a failed commit's output is repeated
+// in the failure report; the full output has already been shown above it.
+const maxCommitOutputLines = 20
+
+// errCommitReported is returned once reportCommitFailure has explained the
+// failure, so callers do not print it again.
+var errCommitReported = errors.New("commit failed")
+
+// stringList collects the values of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, " ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// commitFlags are the `git commit` pass-through flags shared by the main
+// command and `gitcomm retry`.
+type commitFlags struct {
+	sign     *bool
+	signoff  *bool
+	noVerify *bool
+	author   *string
+	extra    stringList
+}
+
+func registerCommitFlags(fs *flag.FlagSet) *commitFlags {
+	f := &commitFlags{
+		sign:     fs.Bool("S", false, "GPG-sign the commit"),
+		signoff:  fs.Bool("signoff", false, "Add a Signed-off-by trailer"),
+		noVerify: fs.Bool("no-verify", false, "Bypass the pre-commit and commit-msg hooks"),
+		author:   fs.String("author", "", "Override the commit author (\"Name <email>\")"),
+	}
+	fs.Var(&f.extra, "commit-flag", "Extra flag passed to git commit unchanged (repeatable)")
+	return f
+}
+
+// used reports whether any commit flag was given.
+func (f *commitFlags) used() bool {
+	return *f.sign || *f.signoff || *f.noVerify || *f.author != "" || len(f.extra) > 0
+}
+
+func (f *commitFlags) options(amend, edit bool) (git.CommitOptions, error) {
+	if err := git.ValidateCommitFlags(f.extra); err != nil {
+		return git.CommitOptions{}, err
+	}
+	return git.CommitOptions{
+		Amend:    amend,
+		Edit:     edit,
+		Sign:     *f.sign,
+		Signoff:  *f.signoff,
+		NoVerify: *f.noVerify,
+		Author:   *f.author,
+		Extra:    f.extra,
+	}, nil
+}
+
+// reportCommitFailure explains why git commit failed, saves the message so
+// it is not lost, and tells the user how to retry with the same scope. push
+// and remote say whether the commit was to be pushed, and where.
+func reportCommitFailure(err error, message string, opts git.CommitOptions, scope git.Scope, push bool, remote string) {
+	var commitErr *git.CommitError
+	if !errors.As(err, &commitErr) {
+		fmt.Printf("❌ Error committing: %v\n", err)
+		return
+	}
+	if commitErr.Message != "" {
+		message = commitErr.Message
+	}
+
+	switch len(commitErr.Hooks) {
+	case 0:
+		fmt.Printf("❌ git commit failed (exit status %d).\n", commitErr.ExitCode)
+	case 1:
+		fmt.Printf("❌ The %s hook rejected the commit (exit status %d).\n", commitErr.Hooks[0], commitErr.ExitCode)
+	default:
+		fmt.Printf("❌ The commit was rejected, most likely by the %s hook (exit status %d).\n", strings.Join(commitErr.Hooks, " or "), commitErr.ExitCode)
+	}
+	if commitErr.Output != "" {
+		lines := strings.Split(commitErr.Output, "\n")
+		if len(lines) > maxCommitOutputLines {
+			fmt.Printf("   Output (last %d of %d lines):\n", maxCommitOutputLines, len(lines))
+			lines = lines[len(lines)-maxCommitOutputLines:]
+		} else {
+			fmt.Println("   Output:")
+		}
+		for _, line := range lines {
+			fmt.Println("   │ " + line)
+		}
+	}
+
+	path, saveErr := git.SaveMessage(message)
+	if saveErr != nil {
+		diag.Error("main", "failed to save commit message", "error", saveErr)
+		fmt.Printf("⚠️  Could not save the commit message: %v\n", saveErr)
+		return
+	}
+	diag.Info("main", "saved commit message after failed commit", "path", path)
+	fmt.Printf("💾 The commit message was saved to %s\n", path)
+	fmt.Println("   Fix the problem, then commit with it using:")
+	fmt.Printf("     %s\n", strings.Join(append([]string{retryCommand(opts, push, remote)}, scopeArgs(scope)...), " "))
+	fmt.Printf("   or: %s\n", strings.Join(append([]string{"git commit -F", strconv.Quote(path)}, scopeArgs(scope)...), " "))
+}
+
+// retryCommand returns the `gitcomm retry` invocation that repeats opts,
+// followed by the push when push is set.
+func retryCommand(opts git.CommitOptions, push bool, remote string) string {
+	args := []string{"gitcomm", "retry"}
+	if opts.Amend {
+		args = append(args, "-amend")
+	}
+	if opts.Edit {
+		args = append(args, "-e")
+	}
+	if push {
+		args = append(args, "-push")
+		if remote != "" {
+			args = append(args, "-remote", shellArg(remote))
+		}
+	}
+	if opts.Sign {
+		args = append(args, "-S")
+	}
+	if opts.Signoff {
+		args = append(args, "-signoff")
+	}
+	if opts.NoVerify {
+		args = append(args, "-no-verify")
+	}
+	if opts.Author != "" {
+		args = append(args, "-author", strconv.Quote(opts.Author))
+	}
+	for _, extra := range opts.Extra {
+		args = append(args, "-commit-flag", strconv.Quote(extra))
+	}
+	return strings.Join(args, " ")
+}
+
+// runRetry commits with the message saved by a failed commit, without
+// calling the model again.
+func runRetry(args []string) error {
+	fs := flag.NewFlagSet("retry", flag.ContinueOnError)
+	amend := fs.Bool("amend", false, "Amend HEAD instead of creating a new commit")
+	edit := fs.Bool("e", false, "Review the saved message in git's editor first")
+	push := fs.Bool("push", false, "Push after committing")
+	remote := fs.String("remote", "", "Remote to push to with -push")
+	all := fs.Bool("a", false, "Include modified and deleted tracked files, like git commit -a")
+	flags := registerCommitFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	paths, dashDash := pathspecs(args, fs.Args())
+	if fs.NArg() > 0 && !dashDash {
+		return fmt.Errorf("unexpected argument %q; usage: gitcomm retry [-amend] [-e] [-a] [-push [-remote <name>]] [commit flags] [-- <pathspec>...]", fs.Arg(0))
+	}
+	scope := git.Scope{Paths: paths, All: *all}
+	if msg := checkScope(scope, dashDash, false, true); msg != "" {
+		return fmt.Errorf("%s", strings.TrimSuffix(msg, "."))
+	}
+	opts, err := flags.options(*amend, *edit)
+	if err != nil {
+		return err
+	}
+
+	if *push && git.RebaseInProgress() {
+		return fmt.Errorf("a rebase is in progress; finish it with `git rebase --continue` before pushing")
+	}
+
+	message, err := git.SavedMessage()
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		return fmt.Errorf("there is no saved commit message; it is only kept after a commit fails")
+	}
+
+	fmt.Println("📝 Saved Commit Message:")
+	fmt.Println("┌" + strings.Repeat("─", 50))
+	fmt.Println(message)
+	fmt.Println("└" + strings.Repeat("─", 50))
+	if *amend {
+		fmt.Println("\n✏️  Amending HEAD with the saved message...")
+	} else {
+		fmt.Println("\n💾 Committing with the saved message...")
+	}
+	var scoped *git.ScopedIndex
+	if !scope.Empty() {
+		if scoped, err = repo.PrepareScope(scope); err != nil {
+			return err
+		}
+		defer scoped.Close()
+	}
+	if err := commitScoped(scoped, message, opts); err != nil {
+		reportCommitFailure(err, message, opts, scope, *push, *remote)
+		return errCommitReported
+	}
+	if err := git.ClearSavedMessage(); err != nil {
+		diag.Warn("main", "failed to remove saved commit message", "error", err)
+	}
+	fmt.Println("✅ Changes committed successfully!")
+
+	if *push {
+		pushAfterCommit(*remote, *amend)
+	}
+	return nil
+}
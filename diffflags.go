@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/config"
+	"github.com/ktappdev/gitcomm/internal/git"
+)
+
+// diffFlags are the command-line overrides for how the diff sent to the
+// model is produced. Only flags given explicitly override config.json, so
+// -w=false can turn off a configured diff_ignore_whitespace.
+type diffFlags struct {
+	fs               *flag.FlagSet
+	noRenames        *bool
+	findCopies       *bool
+	ignoreWhitespace *bool
+	ignoreBlankLines *bool
+	context          *int
+	functionContext  *bool
+	algorithm        *string
+}
+
+// cliDiffFlags holds the top-level diff flags once they are parsed, so every
+// command that collects a diff honours them.
+var cliDiffFlags *diffFlags
+
+func registerDiffFlags(fs *flag.FlagSet) *diffFlags {
+	return &diffFlags{
+		fs:               fs,
+		noRenames:        fs.Bool("no-renames", false, "Turn off rename detection in the diff"),
+		findCopies:       fs.Bool("find-copies", false, "Detect copied files in the diff"),
+		ignoreWhitespace: fs.Bool("w", false, "Ignore whitespace changes in the diff"),
+		ignoreBlankLines: fs.Bool("ignore-blank-lines", false, "Ignore changes whose lines are all blank"),
+		context:          fs.Int("U", 0, "Lines of context around each change (default: git's)"),
+		functionContext:  fs.Bool("function-context", false, "Show the whole function around each change"),
+		algorithm:        fs.String("diff-algorithm", "", "Diff algorithm: "+strings.Join(config.DiffAlgorithms, ", ")),
+	}
+}
+
+// validate checks the values of the flags that were given.
+func (f *diffFlags) validate() error {
+	if *f.context < 0 {
+		return fmt.Errorf("-U must not be negative")
+	}
+	if name := strings.ToLower(*f.algorithm); name != "" && !config.ValidDiffAlgorithm(name) {
+		return fmt.Errorf("unknown -diff-algorithm %q; use one of %s", *f.algorithm, strings.Join(config.DiffAlgorithms, ", "))
+	}
+	return nil
+}
+
+// apply copies the flags that were given onto opts.
+func (f *diffFlags) apply(opts *git.DiffOptions) {
+	if f == nil {
+		return
+	}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "no-renames":
+			opts.NoRenames = *f.noRenames
+		case "find-copies":
+			opts.FindCopies = *f.findCopies
+		case "w":
+			opts.IgnoreWhitespace = *f.ignoreWhitespace
+		case "ignore-blank-lines":
+			opts.IgnoreBlankLines = *f.ignoreBlankLines
+		case "U":
+			context := *f.context
+			opts.Context = &context
+		case "function-context":
+			opts.FunctionContext = *f.functionContext
+		case "diff-algorithm":
+			opts.Algorithm = strings.ToLower(*f.algorithm)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/analyzer"
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+// diffInputFlags are the top-level flags that still mean something when
+// the diff is read from stdin or -diff-file. The rest commit, stage or
+// shape a diff gitcomm collects itself.
+var diffInputFlags = map[string]bool{"diff-file": true, "C": true, "debug": true}
+
+// readsDiffInput reports whether the command line asks for a message for a
+// diff given on stdin (`gitcomm -`) or in a file.
+func readsDiffInput(diffFile string, args []string, dashDash bool) bool {
+	return diffFile != "" || (!dashDash && len(args) > 0 && args[0] == "-")
+}
+
+// checkDiffInput rejects arguments and flags that have no meaning for a diff
+// read from stdin or a file. set holds the names of the flags given.
+func checkDiffInput(diffFile string, args []string, dashDash bool, set []string) error {
+	switch {
+	case diffFile != "" && len(args) > 0:
+		return fmt.Errorf("-diff-file takes no other arguments; got %s", strings.Join(args, " "))
+	case diffFile == "" && len(args) > 1:
+		return fmt.Errorf("usage: gitcomm - reads a diff from stdin and takes no other arguments")
+	case dashDash:
+		return fmt.Errorf("paths select staged changes; filter the diff before piping it instead")
+	}
+	var rejected []string
+	for _, name := range set {
+		if !diffInputFlags[name] {
+			rejected = append(rejected, "-"+name)
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("%s cannot be used with a diff from stdin or -diff-file, which only prints the message", strings.Join(rejected, ", "))
+	}
+	return nil
+}
+
+// runDiffInput generates a message for a diff produced elsewhere, such as
+// `git diff A B` output or a format-patch file, and prints only the message
+// so it can be piped; progress goes to stderr. The diff goes through the
+// same filtering, collapsing and truncation as the staged changes.
+func runDiffInput(diffFile string) error {
+	text, source, err := readDiffInput(diffFile)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%s is empty", source)
+	}
+	opts := loadDiffOptions()
+	opts.Progress = os.Stderr
+	changes := repo.ChangesFromPatch(text, opts)
+	if len(changes.Files) == 0 {
+		return fmt.Errorf("%s does not contain a diff", source)
+	}
+
+	history, examples := loadStyleHistory("HEAD", changes.Files)
+	message, err := analyzer.AnalyzeChanges(analyzer.Input{Diff: changes.Diff, Files: changes.Files, History: history, StyleExamples: examples, Submodules: changes.Submodules, Progress: os.Stderr})
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		return fmt.Errorf("could not extract a commit message from the analysis")
+	}
+	diag.Info("main", "generated message for diff input", "source", source, "files", len(changes.Files), "length", len(message))
+	_, err = fmt.Fprintln(os.Stdout, message)
+	return err
+}
+
+// readDiffInput reads the diff from path, or from stdin when path is "" or
+// "-", and returns it with a name for messages.
+func readDiffInput(path string) (string, string, error) {
+	if path != "" && path != "-" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		return string(data), path, nil
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "⌨️  Reading a diff from stdin; end it with Ctrl-D.")
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the diff from stdin: %w", err)
+	}
+	return string(data), "stdin", nil
+}
+
+// setFlags returns the names of the top-level flags given on the command
+// line.
+func setFlags() []string {
+	var names []string
+	flag.Visit(func(f *flag.Flag) { names = append(names, f.Name) })
+	return names
+}
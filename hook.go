@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/analyzer"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+	"github.com/ktappdev/gitcomm/internal/hook"
+)
+
+const hookUsage = "usage: gitcomm hook install|uninstall"
+
+func runHookCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(hookUsage)
+	}
+	switch args[0] {
+	case "install":
+		return installHook()
+	case "uninstall":
+		return uninstallHook()
+	case "run":
+		runHook(args[1:])
+		return nil
+	default:
+		return fmt.Errorf("unknown hook command %q; %s", args[0], hookUsage)
+	}
+}
+
+// hookPath returns where the prepare-commit-msg hook lives. Husky points
+// core.hooksPath at its generated .husky/_ directory and runs user hooks from
+// .husky itself, so the hook goes there instead.
+func hookPath() (string, error) {
+	dir, err := git.HooksDir()
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(dir) == "_" && filepath.Base(filepath.Dir(dir)) == ".husky" {
+		dir = filepath.Dir(dir)
+	}
+	return filepath.Join(dir, hook.PrepareCommitMsg), nil
+}
+
+func installHook() error {
+	path, err := hookPath()
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	existing, _ := os.ReadFile(path)
+
+	action, err := hook.Install(path, hook.PrepareCommitMsg, executable)
+	if err != nil {
+		return err
+	}
+	diag.Info("main", "installed hook", "path", path, "action", action)
+	fmt.Printf("✅ Installed %s hook (%s): %s\n", hook.PrepareCommitMsg, action, path)
+	if hooksPath := git.ConfigValue("core.hooksPath"); hooksPath != "" {
+		fmt.Printf("   core.hooksPath is set to %s\n", hooksPath)
+	}
+	if manager := hookManager(string(existing)); manager != "" {
+		fmt.Printf("   This hook is managed by %s; if it regenerates the hook, run `gitcomm hook install` again.\n", manager)
+	}
+	fmt.Println("   Plain `git commit` now opens the editor with a generated message.")
+	fmt.Println("   Set GITCOMM_HOOK=0 to skip it for a single commit.")
+	return nil
+}
+
+func uninstallHook() error {
+	path, err := hookPath()
+	if err != nil {
+		return err
+	}
+	found, err := hook.Uninstall(path)
+	if err != nil {
+		return err
+	}
+	if !found {
+		fmt.Printf("No gitcomm hook found in %s\n", path)
+		return nil
+	}
+	diag.Info("main", "uninstalled hook", "path", path)
+	fmt.Printf("✅ Removed the gitcomm %s hook from %s\n", hook.PrepareCommitMsg, path)
+	return nil
+}
+
+func hookManager(content string) string {
+	switch {
+	case strings.Contains(content, "lefthook"):
+		return "lefthook"
+	case strings.Contains(content, "pre-commit.com") || strings.Contains(content, "File generated by pre-commit"):
+		return "pre-commit"
+	case strings.Contains(content, "husky"):
+		return "husky"
+	}
+	return ""
+}
+
+// runHook is called by the installed hook. It always fails open: problems are
+// reported on stderr and the commit goes ahead with git's normal message.
+func runHook(args []string) {
+	if len(args) == 0 || args[0] != hook.PrepareCommitMsg {
+		fmt.Fprintf(os.Stderr, "gitcomm: unsupported hook %v\n", args)
+		return
+	}
+	if err := prepareCommitMsg(args[1:]); err != nil {
+		diag.Warn("main", "prepare-commit-msg hook failed", "error", err)
+		fmt.Fprintf(os.Stderr, "⚠️  gitcomm: %v; continuing with git's default message\n", err)
+	}
+}
+
+// prepareCommitMsg fills the commit message file for a plain `git commit`.
+// git passes the message source as the second argument: message (-m/-F),
+// template, merge, squash or commit (--amend, -c, -C). All of those already
+// have a message, so only an empty source is handled.
+func prepareCommitMsg(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing commit message file argument")
+	}
+	msgFile := args[0]
+	source := ""
+	if len(args) > 1 {
+		source = args[1]
+	}
+	if source != "" {
+		diag.Debug("main", "hook skipped", "source", source)
+		return nil
+	}
+
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		return err
+	}
+	original := string(data)
+	if hasMessageContent(original, git.CommentChar()) {
+		diag.Debug("main", "hook skipped; message file already has content")
+		return nil
+	}
+
+	// git shows the hook's stdout to the user, but progress belongs on
+	// stderr with the warnings.
+	opts := loadDiffOptions()
+	opts.Progress = os.Stderr
+	changes, err := repo.GetStagedChanges(opts)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(changes.Diff) == "" {
+		return nil
+	}
+	history, examples := loadStyleHistory("HEAD", changes.Files)
+	message, err := analyzer.AnalyzeChanges(analyzer.Input{Diff: changes.Diff, Files: changes.Files, History: history, StyleExamples: examples, Submodules: changes.Submodules, Symbols: changes.Symbols, Progress: os.Stderr})
+	if err != nil {
+		return err
+	}
+	if message, err = addTickets(message); err != nil {
+		return err
+	}
+	if message, err = addTrailers(message); err != nil {
+		return err
+	}
+
+	separator := "\n"
+	if !strings.HasPrefix(original, "\n") {
+		separator = "\n\n"
+	}
+	return os.WriteFile(msgFile, []byte(strings.TrimRight(message, "\n")+separator+original), 0o644)
+}
+
+// scissors is the line, after the comment character, below which git drops
+// everything; `git commit -v` puts the diff there.
+const scissors = " ------------------------ >8 ------------------------"
+
+// hasMessageContent reports whether the message file holds anything besides
+// comments and blank lines, such as text written by another hook. Anything
+// below the scissors line is ignored.
+func hasMessageContent(content, commentChar string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimRight(line, "\r") == commentChar+scissors {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, commentChar) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,11 +2,15 @@ package analyzer
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"unicode"
 
 	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
 	"github.com/ktappdev/gitcomm/internal/llm"
+	"github.com/ktappdev/gitcomm/internal/symbols"
 )
 
 const (
@@ -16,22 +20,69 @@ const (
 	maxCompactLineLength      = 160
 )
 
-func AnalyzeChanges(diff string) (string, error) {
-	fmt.Println("🤖 Generating commit message...")
+// Input is everything AnalyzeChanges needs to describe a change.
+type Input struct {
+	// Diff is the patch text sent to the model, possibly already truncated.
+	Diff string
+	// Files summarizes every changed file, including ones missing from Diff.
+	Files []gitdiff.FileStat
+	// History holds recent commit messages, newest first, from which the
+	// repository's commit style is learned. Empty disables style guidance.
+	History []string
+	// StyleExamples is how many of History are quoted in the prompt.
+	StyleExamples int
+	// Operation is set when the commit concludes an unfinished merge,
+	// rebase step, cherry-pick or revert.
+	Operation *Operation
+	// Submodules describes staged submodule bumps.
+	Submodules []gitdiff.Submodule
+	// Symbols lists the functions, methods and types the change touches.
+	Symbols []symbols.Change
+	// Progress receives progress notes; nil means os.Stdout. Commands whose
+	// result is piped send them to stderr.
+	Progress io.Writer
+}
+
+// progressTo returns w, or os.Stdout when w is nil.
+func progressTo(w io.Writer) io.Writer {
+	if w == nil {
+		return os.Stdout
+	}
+	return w
+}
+
+func AnalyzeChanges(input Input) (string, error) {
+	diff := input.Diff
+	progress := progressTo(input.Progress)
+	fmt.Fprintln(progress, "🤖 Generating commit message...")
 	if strings.TrimSpace(diff) == "" {
 		diag.Error("analyzer", "refusing to analyze empty diff")
 		return "", fmt.Errorf("no staged diff content available to analyze")
 	}
 
-	client, err := llm.NewClient(llm.ClientConfig{MaxTokens: 400, Temperature: 0.7})
+	client, err := llm.NewClient(llm.ClientConfig{MaxTokens: 400, Temperature: 0.7, Progress: progress})
 	if err != nil {
 		return "", err
 	}
 	defer client.Close()
 
-	analysisDiff, compacted := prepareDiffForAnalysis(diff)
-	prompt := buildPrompt(analysisDiff)
-	diag.Info("analyzer", "built prompt", "diff_chars", len(diff), "analysis_diff_chars", len(analysisDiff), "prompt_chars", len(prompt), "compacted", compacted)
+	patch := gitdiff.Parse(diff)
+	analysisDiff, compacted := preparePatchForAnalysis(patch)
+	summary := buildFileSummary(input.Files, patch)
+	style := detectCommitStyle(input.History, input.StyleExamples)
+	guidance := style.guidance()
+	if changed := symbolGuidance(input.Symbols); changed != "" {
+		guidance = strings.TrimSpace(changed + "\n\n" + guidance)
+	}
+	if submodules := submoduleGuidance(input.Submodules); submodules != "" {
+		guidance = strings.TrimSpace(submodules + "\n\n" + guidance)
+	}
+	if operation := input.Operation.guidance(); operation != "" {
+		guidance = strings.TrimSpace(operation + "\n\n" + guidance)
+	}
+	prompt := buildPrompt(summary, analysisDiff, guidance)
+	added, removed := patch.Stats()
+	diag.Info("analyzer", "built prompt", "diff_chars", len(diff), "files", len(patch.Files), "added", added, "removed", removed, "summary_files", len(input.Files), "symbols", len(input.Symbols), "analysis_diff_chars", len(analysisDiff), "prompt_chars", len(prompt), "compacted", compacted, "style_samples", style.samples, "style_conventional", style.conventional)
 
 	response, err := client.SendPrompt(prompt)
 	if err != nil {
@@ -43,14 +94,26 @@ func AnalyzeChanges(diff string) (string, error) {
 		diag.Error("analyzer", "failed to parse commit message", "error", err, "response_snippet", diag.Snippet(response, 300))
 		return "", err
 	}
+	if input.Operation != nil {
+		commitMessage = KeepProvenance(commitMessage, input.Operation.Message)
+	}
 	diag.Info("analyzer", "parsed commit message", "response_chars", len(response), "commit_chars", len(commitMessage))
 	return commitMessage, nil
 }
 
-func buildPrompt(diff string) string {
+// buildPrompt assembles the commit prompt; guidance holds optional sections
+// about the repository's style or the operation in progress.
+func buildPrompt(summary, diff, guidance string) string {
+	header := ""
+	if summary != "" {
+		header = "Changed Files:\n" + summary + "\n\n"
+	}
+	if guidance != "" {
+		guidance += "\n\n"
+	}
 	return `Analyze the following git diff and generate a proper Git commit message with both a subject line and detailed body.
 
-Git Diff:
+` + header + `Git Diff:
 ` + diff + `
 
 Please follow these Git commit message best practices:
@@ -59,7 +122,7 @@ Please follow these Git commit message best practices:
 - Body: Detailed explanation, wrap lines at 72 characters
 - Explain WHAT changed and WHY (not just how)
 
-Format your response as follows:
+` + guidance + `Format your response as follows:
 Generated Commit Message:
 [Subject line - 50-72 characters]
 
@@ -68,12 +131,61 @@ Include context about what was changed and why it was necessary.
 You can use multiple paragraphs if needed.]`
 }
 
+// buildFileSummary lists every changed file with its status and line counts,
+// noting files whose hunks are missing from or cut short in the diff below.
+func buildFileSummary(files []gitdiff.FileStat, shown *gitdiff.Patch) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	shownFiles := make(map[string]*gitdiff.File, len(shown.Files))
+	for _, file := range shown.Files {
+		shownFiles[file.Path()] = file
+	}
+
+	totalAdded, totalRemoved := 0, 0
+	lines := make([]string, 0, len(files)+1)
+	for _, stat := range files {
+		totalAdded += stat.Added
+		totalRemoved += stat.Removed
+		counts := fmt.Sprintf("+%d -%d", stat.Added, stat.Removed)
+		if stat.Binary {
+			counts = "binary"
+		}
+		line := fmt.Sprintf("%s %s (%s)", stat.Status, stat.DisplayPath(), counts)
+		if note := summaryNote(stat, shownFiles[stat.Path]); note != "" {
+			line += " [" + note + "]"
+		}
+		lines = append(lines, line)
+	}
+	header := fmt.Sprintf("%d files changed, %d insertions(+), %d deletions(-)", len(files), totalAdded, totalRemoved)
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+func summaryNote(stat gitdiff.FileStat, shown *gitdiff.File) string {
+	if stat.Omitted != "" {
+		return stat.Omitted
+	}
+	if shown == nil {
+		return "not shown in diff below"
+	}
+	if !stat.Binary && (shown.Added < stat.Added || shown.Removed < stat.Removed) {
+		return "diff truncated"
+	}
+	return ""
+}
+
 func prepareDiffForAnalysis(diff string) (string, bool) {
+	return preparePatchForAnalysis(gitdiff.Parse(diff))
+}
+
+func preparePatchForAnalysis(patch *gitdiff.Patch) (string, bool) {
+	diff := patch.String()
 	if len(diff) <= compactDiffThresholdChars {
 		diag.Debug("analyzer", "using full diff for analysis", "diff_chars", len(diff), "threshold_chars", compactDiffThresholdChars)
 		return diff, false
 	}
-	compacted := compactDiff(diff)
+	compacted := compactPatch(patch)
 	if compacted == "" || len(compacted) >= len(diff) {
 		diag.Warn("analyzer", "diff compaction skipped; no improvement", "diff_chars", len(diff), "compacted_chars", len(compacted), "threshold_chars", compactDiffThresholdChars)
 		return diff, false
@@ -84,16 +196,37 @@ func prepareDiffForAnalysis(diff string) (string, bool) {
 }
 
 func compactDiff(diff string) string {
-	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
-	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
-		return ""
+	return compactPatch(gitdiff.Parse(diff))
+}
+
+func compactPatch(patch *gitdiff.Patch) string {
+	out := make([]string, 0, patch.LineCount()/2)
+	for _, line := range patch.Preamble {
+		out = append(out, truncateCompactLine(line))
 	}
+	for _, file := range patch.Files {
+		out = append(out, file.Header...)
+		if len(file.BinaryPatch) > 0 {
+			out = append(out, fmt.Sprintf("[[gitcomm: %d lines of binary patch data omitted in compact diff]]", len(file.BinaryPatch)))
+		}
+		for _, hunk := range file.Hunks {
+			out = append(out, hunk.Header)
+			out = append(out, compactHunkLines(hunk.Lines)...)
+		}
+		for _, line := range file.Trailer {
+			out = append(out, truncateCompactLine(line))
+		}
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
 
-	out := make([]string, 0, len(lines)/2)
+// compactHunkLines keeps the first few context lines of a hunk and a sample of
+// each run of changes, replacing the rest with explicit omission markers.
+func compactHunkLines(lines []gitdiff.Line) []string {
+	out := make([]string, 0, len(lines))
 	contextSeen := 0
 	omittedContext := 0
 	changeLines := make([]string, 0, maxCompactChangeLines)
-	inHunk := false
 
 	flushOmittedContext := func() {
 		if omittedContext > 0 {
@@ -101,7 +234,7 @@ func compactDiff(diff string) string {
 			omittedContext = 0
 		}
 	}
-	flushHunkChanges := func() {
+	flushChanges := func() {
 		if len(changeLines) == 0 {
 			return
 		}
@@ -109,51 +242,28 @@ func compactDiff(diff string) string {
 		out = append(out, sampleChangeLines(changeLines)...)
 		changeLines = changeLines[:0]
 	}
-	resetHunk := func() {
-		contextSeen = 0
-		omittedContext = 0
-		changeLines = changeLines[:0]
-	}
 
 	for _, line := range lines {
-		switch {
-		case strings.HasPrefix(line, "diff --git "):
-			flushHunkChanges()
-			out = append(out, line)
-			resetHunk()
-			inHunk = false
-		case strings.HasPrefix(line, "index "), strings.HasPrefix(line, "new file mode "), strings.HasPrefix(line, "deleted file mode "), strings.HasPrefix(line, "similarity index "), strings.HasPrefix(line, "rename from "), strings.HasPrefix(line, "rename to "), strings.HasPrefix(line, "Binary files "):
-			flushHunkChanges()
-			out = append(out, line)
-		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
-			flushHunkChanges()
-			out = append(out, line)
-		case strings.HasPrefix(line, "@@"):
-			flushHunkChanges()
-			out = append(out, line)
-			resetHunk()
-			inHunk = true
-		case inHunk && strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
-			changeLines = append(changeLines, truncateCompactLine(line))
-		case inHunk && strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
-			changeLines = append(changeLines, truncateCompactLine(line))
-		case inHunk && strings.HasPrefix(line, " "):
-			flushHunkChanges()
+		switch line.Kind {
+		case gitdiff.LineAdded, gitdiff.LineRemoved:
+			changeLines = append(changeLines, truncateCompactLine(line.String()))
+		case gitdiff.LineContext:
+			flushChanges()
 			if contextSeen < maxCompactContextLines {
 				flushOmittedContext()
-				out = append(out, truncateCompactLine(line))
+				out = append(out, truncateCompactLine(line.String()))
 				contextSeen++
 			} else {
 				omittedContext++
 			}
 		default:
-			flushHunkChanges()
-			out = append(out, truncateCompactLine(line))
+			flushChanges()
+			out = append(out, truncateCompactLine(line.String()))
 		}
 	}
-	flushHunkChanges()
+	flushChanges()
 	flushOmittedContext()
-	return strings.TrimSpace(strings.Join(out, "\n"))
+	return out
 }
 
 func sampleChangeLines(lines []string) []string {
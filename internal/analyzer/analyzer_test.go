@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
 )
 
 func TestPrepareDiffForAnalysisUsesFullDiffWhenSmall(t *testing.T) {
@@ -164,3 +166,75 @@ func TestExtractCommitMessageRejectsCommentary(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestCompactDiffOmitsBinaryPatchData(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/icon.png b/icon.png",
+		"new file mode 100644",
+		"index 0000000..9fbd37b",
+		"GIT binary patch",
+		"literal 12",
+		"TcmZ?wbh1)H;-5Ij0RRBY4qOC5",
+		"",
+		"literal 0",
+		"HcmV?d00001",
+		"",
+		"diff --git a/app.go b/app.go",
+		"--- a/app.go",
+		"+++ b/app.go",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+	}, "\n")
+	got := compactDiff(diff)
+	if strings.Contains(got, "TcmZ?wbh1") {
+		t.Fatalf("expected binary patch data to be omitted: %s", got)
+	}
+	for _, want := range []string{"diff --git a/icon.png b/icon.png", "[[gitcomm: 7 lines of binary patch data omitted in compact diff]]", "+new"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("compacted diff missing %q\n%s", want, got)
+		}
+	}
+}
+
+func TestBuildFileSummaryListsFilesMissingFromDiff(t *testing.T) {
+	shown := gitdiff.Parse(strings.Join([]string{
+		"diff --git a/app.go b/app.go",
+		"--- a/app.go",
+		"+++ b/app.go",
+		"@@ -1,2 +1,2 @@",
+		"-old",
+		"+new",
+		"... (truncated, 40 more lines)",
+	}, "\n"))
+	files := []gitdiff.FileStat{
+		{Path: "app.go", Status: gitdiff.StatusModified, Added: 3, Removed: 1},
+		{Path: "new/name.go", OldPath: "old/name.go", Status: gitdiff.StatusRenamed, Added: 2, Removed: 2},
+		{Path: "logo.png", Status: gitdiff.StatusAdded, Binary: true},
+	}
+
+	got := buildFileSummary(files, shown)
+	for _, want := range []string{
+		"3 files changed, 5 insertions(+), 3 deletions(-)",
+		"M app.go (+3 -1) [diff truncated]",
+		"R old/name.go => new/name.go (+2 -2) [not shown in diff below]",
+		"A logo.png (binary) [not shown in diff below]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary missing %q\n%s", want, got)
+		}
+	}
+
+	prompt := buildPrompt(got, shown.String(), "")
+	if !strings.Contains(prompt, "Changed Files:\n3 files changed") {
+		t.Fatalf("prompt missing summary header:\n%s", prompt)
+	}
+}
+
+func TestBuildFileSummaryReportsOmittedReason(t *testing.T) {
+	files := []gitdiff.FileStat{{Path: "go.sum", Status: gitdiff.StatusModified, Added: 40, Removed: 12, Omitted: "excluded by ignore patterns"}}
+	got := buildFileSummary(files, gitdiff.Parse(""))
+	if !strings.Contains(got, "M go.sum (+40 -12) [excluded by ignore patterns]") {
+		t.Fatalf("unexpected summary:\n%s", got)
+	}
+}
@@ -0,0 +1,180 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/changelog"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/llm"
+)
+
+const (
+	changelogMaxTokens     = 1200
+	changelogChunkChars    = 10000
+	maxChangelogBodyLines  = 8
+	maxChangelogCommitPath = 12
+)
+
+var breakingSubjectExpr = regexp.MustCompile(`^[a-zA-Z]+(\([^)]*\))?!:`)
+
+type changelogChunk struct {
+	text    string
+	commits int
+}
+
+// ChangelogCommit is one commit fed to GenerateReleaseNotes.
+type ChangelogCommit struct {
+	Hash    string
+	Message string
+	Paths   []string
+}
+
+// GenerateReleaseNotes groups the commits into release notes. Commits are
+// described by their messages and changed paths rather than full diffs, and
+// long ranges are split into chunks that are summarized separately and then
+// merged, so nothing is cut off to fit the model context. Progress notes go
+// to progress, or os.Stdout when it is nil.
+func GenerateReleaseNotes(commits []ChangelogCommit, progress io.Writer) (changelog.Notes, error) {
+	progress = progressTo(progress)
+	if len(commits) == 0 {
+		return changelog.Notes{}, fmt.Errorf("no commits to summarize")
+	}
+	chunks := chunkChangelogCommits(commits, changelogChunkChars)
+	if len(chunks) == 1 {
+		fmt.Fprintln(progress, "🤖 Generating release notes...")
+	} else {
+		fmt.Fprintf(progress, "🤖 Generating release notes in %d chunks...\n", len(chunks))
+	}
+
+	client, err := llm.NewClient(llm.ClientConfig{MaxTokens: changelogMaxTokens, Temperature: 0.3, Progress: progress})
+	if err != nil {
+		return changelog.Notes{}, err
+	}
+	defer client.Close()
+
+	var notes changelog.Notes
+	for i, chunk := range chunks {
+		if len(chunks) > 1 {
+			fmt.Fprintf(progress, "   Chunk %d/%d (%d commits)\n", i+1, len(chunks), chunk.commits)
+		}
+		prompt := buildChangelogPrompt(chunk.text)
+		diag.Info("analyzer", "built changelog prompt", "chunk", i+1, "chunks", len(chunks), "prompt_chars", len(prompt))
+		response, err := client.SendPrompt(prompt)
+		if err != nil {
+			return changelog.Notes{}, err
+		}
+		parsed := changelog.ParseNotes(response)
+		if parsed.Empty() {
+			diag.Warn("analyzer", "changelog chunk produced no entries", "chunk", i+1, "response_snippet", diag.Snippet(response, 300))
+			continue
+		}
+		notes.Merge(parsed)
+	}
+	if notes.Empty() {
+		return changelog.Notes{}, fmt.Errorf("model response did not contain any release notes")
+	}
+	return notes, nil
+}
+
+// chunkChangelogCommits renders commits and packs them into chunks of at most
+// budget characters. A single commit larger than the budget gets a chunk of
+// its own.
+func chunkChangelogCommits(commits []ChangelogCommit, budget int) []changelogChunk {
+	var chunks []changelogChunk
+	var current strings.Builder
+	count := 0
+	for _, commit := range commits {
+		entry := formatChangelogCommit(commit)
+		if count > 0 && current.Len()+len(entry)+2 > budget {
+			chunks = append(chunks, changelogChunk{text: current.String(), commits: count})
+			current.Reset()
+			count = 0
+		}
+		if count > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(entry)
+		count++
+	}
+	if count > 0 {
+		chunks = append(chunks, changelogChunk{text: current.String(), commits: count})
+	}
+	return chunks
+}
+
+func formatChangelogCommit(commit ChangelogCommit) string {
+	subject, body := splitSubjectBody(commit.Message)
+	short := commit.Hash
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	lines := []string{"commit " + short, "Subject: " + subject}
+	if isBreakingCommit(subject, body) {
+		lines = append(lines, "Marked as a breaking change by its author.")
+	}
+	if body != "" {
+		bodyLines := strings.Split(body, "\n")
+		if len(bodyLines) > maxChangelogBodyLines {
+			bodyLines = append(bodyLines[:maxChangelogBodyLines], "...")
+		}
+		lines = append(lines, "Body:")
+		for _, line := range bodyLines {
+			lines = append(lines, strings.TrimRight("  "+line, " "))
+		}
+	}
+	if len(commit.Paths) > 0 {
+		paths := commit.Paths
+		suffix := ""
+		if len(paths) > maxChangelogCommitPath {
+			suffix = fmt.Sprintf(" (and %d more)", len(paths)-maxChangelogCommitPath)
+			paths = paths[:maxChangelogCommitPath]
+		}
+		lines = append(lines, "Files: "+strings.Join(paths, ", ")+suffix)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isBreakingCommit recognizes the Conventional Commits markers: "type!:" in
+// the subject or a BREAKING CHANGE footer.
+func isBreakingCommit(subject, body string) bool {
+	if breakingSubjectExpr.MatchString(subject) {
+		return true
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return true
+		}
+	}
+	return false
+}
+
+func buildChangelogPrompt(commits string) string {
+	return `Write release notes for the following git commits.
+
+Commits:
+` + commits + `
+
+Group the notes under these Markdown headings, omitting empty ones:
+### Breaking Changes
+### Added
+### Changed
+### Deprecated
+### Removed
+### Fixed
+### Security
+
+Guidelines:
+- One bullet ("- ") per user-visible change, written for people upgrading
+- Merge commits that describe the same change into one bullet
+- Put new features under Added and bug fixes under Fixed
+- List anything that requires users to change their code, configuration or
+  workflow under Breaking Changes, including commits marked as breaking
+- Leave out purely internal changes (refactoring, tests, CI) unless they
+  affect users
+- Do not invent changes, issue numbers or links
+
+Respond with the headings and bullets only.`
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkChangelogCommitsRespectsBudget(t *testing.T) {
+	commits := make([]ChangelogCommit, 10)
+	for i := range commits {
+		commits[i] = ChangelogCommit{Hash: strings.Repeat("a", 40), Message: "Add feature\n\n" + strings.Repeat("x", 80), Paths: []string{"main.go"}}
+	}
+	entry := len(formatChangelogCommit(commits[0]))
+	chunks := chunkChangelogCommits(commits, entry*3+10)
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+	total := 0
+	for _, chunk := range chunks {
+		if chunk.commits > 1 && len(chunk.text) > entry*3+10 {
+			t.Fatalf("chunk exceeds budget: %d", len(chunk.text))
+		}
+		total += chunk.commits
+	}
+	if total != len(commits) {
+		t.Fatalf("chunks cover %d commits, want %d", total, len(commits))
+	}
+
+	huge := []ChangelogCommit{{Hash: "b", Message: "Huge\n\n" + strings.Repeat("y\n", 50)}}
+	if chunks := chunkChangelogCommits(huge, 10); len(chunks) != 1 || chunks[0].commits != 1 {
+		t.Fatalf("oversized commit should get its own chunk, got %+v", chunks)
+	}
+}
+
+func TestFormatChangelogCommitMarksBreakingChanges(t *testing.T) {
+	paths := make([]string, maxChangelogCommitPath+2)
+	for i := range paths {
+		paths[i] = "file.go"
+	}
+	got := formatChangelogCommit(ChangelogCommit{Hash: "0123456789abcdef", Message: "feat(api)!: drop v1 endpoints", Paths: paths})
+	for _, want := range []string{"commit 0123456\n", "Subject: feat(api)!: drop v1 endpoints", "Marked as a breaking change", "(and 2 more)"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if !isBreakingCommit("Rework config", "Details.\n\nBREAKING CHANGE: keys renamed") {
+		t.Fatal("expected BREAKING CHANGE footer to be detected")
+	}
+	if isBreakingCommit("fix: handle nil", "") {
+		t.Fatal("plain fix should not be breaking")
+	}
+}
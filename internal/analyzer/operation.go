@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operation is the unfinished merge, rebase, cherry-pick or revert a commit
+// concludes.
+type Operation struct {
+	// Kind is "merge", "rebase", "cherry-pick" or "revert".
+	Kind string
+	// Message is the message git prepared, or the message of the commit a
+	// rebase is replaying.
+	Message string
+	// Conflicts lists the paths that had conflicts.
+	Conflicts []string
+}
+
+// provenanceExpr matches the lines git adds to record where a cherry-pick
+// or revert came from.
+var provenanceExpr = regexp.MustCompile(`\(cherry picked from commit [0-9a-f]{7,}\)|This reverts commit [0-9a-f]{7,}(?:,\s+reversing\s+changes\s+made\s+to\s+[0-9a-f]{7,})?\.`)
+
+// guidance describes the operation for the prompt, or "" when there is none.
+func (op *Operation) guidance() string {
+	if op == nil {
+		return ""
+	}
+	prepared := strings.TrimSpace(op.Message)
+	var b strings.Builder
+	switch op.Kind {
+	case "merge":
+		b.WriteString("This commit concludes a merge; the diff is everything the merge brings in. git prepared this message:\n")
+		b.WriteString(prepared + "\n")
+		b.WriteString("Keep git's subject line unchanged. In the body, summarize what the merged branch brings in.")
+	case "rebase":
+		b.WriteString("This commit is made while a rebase is stopped. The commit being replayed had this message, which shows the intent of the change:\n")
+		b.WriteString(prepared + "\n")
+		b.WriteString("Describe the change as it now applies.")
+	case "cherry-pick":
+		b.WriteString("This commit is a cherry-pick of a commit with this message:\n")
+		b.WriteString(prepared + "\n")
+		b.WriteString(`Describe the change as it applies here. Do not write a "(cherry picked from commit ...)" line; it is added automatically.`)
+	case "revert":
+		b.WriteString("This commit reverts an earlier commit. git prepared this message:\n")
+		b.WriteString(prepared + "\n")
+		b.WriteString(`Keep git's "Revert" subject line and explain in the body what is being undone. Do not write a "This reverts commit ..." line; it is added automatically.`)
+	default:
+		return ""
+	}
+	if len(op.Conflicts) > 0 {
+		fmt.Fprintf(&b, "\nConflicts were resolved in: %s. Explain in the body how each conflict was resolved, based on the diff.", strings.Join(op.Conflicts, ", "))
+	}
+	return b.String()
+}
+
+// KeepProvenance appends the cherry-pick and revert provenance lines found
+// in prepared that are missing from message.
+func KeepProvenance(message, prepared string) string {
+	normalized := strings.Join(strings.Fields(message), " ")
+	var missing []string
+	for _, line := range provenanceExpr.FindAllString(prepared, -1) {
+		if !strings.Contains(normalized, strings.Join(strings.Fields(line), " ")) {
+			missing = append(missing, line)
+		}
+	}
+	if len(missing) == 0 {
+		return message
+	}
+	return strings.TrimRight(message, "\n") + "\n\n" + strings.Join(missing, "\n")
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeepProvenanceAppendsMissingLines(t *testing.T) {
+	prepared := "Revert \"Add cache\"\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567, reversing\nchanges made to fedcba9876543210fedcba9876543210fedcba98.\n"
+	got := KeepProvenance("Revert \"Add cache\"\n\nThe cache served stale data.", prepared)
+	want := "Revert \"Add cache\"\n\nThe cache served stale data.\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567, reversing\nchanges made to fedcba9876543210fedcba9876543210fedcba98."
+	if got != want {
+		t.Fatalf("KeepProvenance() =\n%s\nwant:\n%s", got, want)
+	}
+	if again := KeepProvenance(got, prepared); again != got {
+		t.Fatalf("provenance already present should not be repeated:\n%s", again)
+	}
+
+	cherry := KeepProvenance("Fix crash", "Fix crash\n\n(cherry picked from commit abcdef1234567)")
+	if cherry != "Fix crash\n\n(cherry picked from commit abcdef1234567)" {
+		t.Fatalf("unexpected cherry-pick message:\n%s", cherry)
+	}
+	if plain := KeepProvenance("Merge branch 'x'", "Merge branch 'x'"); plain != "Merge branch 'x'" {
+		t.Fatalf("merge messages have no provenance, got:\n%s", plain)
+	}
+}
+
+func TestOperationGuidance(t *testing.T) {
+	var none *Operation
+	if none.guidance() != "" {
+		t.Fatal("nil operation should add nothing to the prompt")
+	}
+	merge := &Operation{Kind: "merge", Message: "Merge branch 'feature'", Conflicts: []string{"a.go", "b.go"}}
+	guidance := merge.guidance()
+	for _, want := range []string{"concludes a merge", "Merge branch 'feature'", "Keep git's subject line unchanged", "Conflicts were resolved in: a.go, b.go"} {
+		if !strings.Contains(guidance, want) {
+			t.Fatalf("guidance missing %q:\n%s", want, guidance)
+		}
+	}
+	prompt := buildPrompt("", "diff", guidance)
+	if !strings.Contains(prompt, "b.go. Explain in the body how each conflict was resolved, based on the diff.\n\nFormat your response") {
+		t.Fatalf("operation guidance should precede the response format:\n%s", prompt)
+	}
+}
@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/llm"
+)
+
+const (
+	prMaxTokens          = 1200
+	maxPRCommits         = 50
+	maxPRCommitBodyLines = 12
+	maxPRTitleLength     = 100
+)
+
+var templateCommentExpr = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// PRInput describes a branch for GeneratePullRequest.
+type PRInput struct {
+	Input
+	Branch string
+	Base   string
+	// Commits holds the full message of each commit on the branch, oldest
+	// first.
+	Commits []string
+	// Template is the repository's pull request template, if any.
+	Template string
+}
+
+type PullRequest struct {
+	Title string
+	Body  string
+}
+
+func (pr PullRequest) String() string {
+	if pr.Body == "" {
+		return pr.Title
+	}
+	return pr.Title + "\n\n" + pr.Body
+}
+
+// GeneratePullRequest asks the model for a PR title and Markdown description
+// covering the branch diff and its commit messages.
+func GeneratePullRequest(input PRInput) (PullRequest, error) {
+	progress := progressTo(input.Progress)
+	fmt.Fprintln(progress, "🤖 Generating pull request description...")
+	if strings.TrimSpace(input.Diff) == "" {
+		diag.Error("analyzer", "refusing to describe empty branch diff")
+		return PullRequest{}, fmt.Errorf("branch has no changes against %s", input.Base)
+	}
+
+	client, err := llm.NewClient(llm.ClientConfig{MaxTokens: prMaxTokens, Temperature: 0.7, Progress: progress})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	defer client.Close()
+
+	patch := gitdiff.Parse(input.Diff)
+	analysisDiff, compacted := preparePatchForAnalysis(patch)
+	summary := buildFileSummary(input.Files, patch)
+	prompt := buildPRPrompt(input, summary, analysisDiff)
+	diag.Info("analyzer", "built pr prompt", "branch", input.Branch, "base", input.Base, "commits", len(input.Commits), "has_template", input.Template != "", "analysis_diff_chars", len(analysisDiff), "prompt_chars", len(prompt), "compacted", compacted)
+
+	response, err := client.SendPrompt(prompt)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	pr, err := extractPullRequest(response)
+	if err != nil {
+		diag.Error("analyzer", "failed to parse pull request", "error", err, "response_snippet", diag.Snippet(response, 300))
+		return PullRequest{}, err
+	}
+	if title, shortened := shortenTitle(pr.Title, maxPRTitleLength); shortened {
+		diag.Warn("analyzer", "shortened long pull request title", "title_chars", len(pr.Title), "max", maxPRTitleLength)
+		fmt.Fprintf(progress, "⚠️  The generated title was longer than %d characters; shortened it to %q.\n", maxPRTitleLength, title)
+		pr.Title = title
+	}
+	diag.Info("analyzer", "parsed pull request", "response_chars", len(response), "title_chars", len(pr.Title), "body_chars", len(pr.Body))
+	return pr, nil
+}
+
+func buildPRPrompt(input PRInput, summary, diff string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a pull request title and description for merging branch %q into %q.\n\n", input.Branch, input.Base)
+
+	if len(input.Commits) > 0 {
+		b.WriteString("Commits on the branch (oldest first):\n")
+		b.WriteString(formatPRCommits(input.Commits))
+		b.WriteString("\n\n")
+	}
+	if summary != "" {
+		b.WriteString("Changed Files:\n" + summary + "\n\n")
+	}
+	b.WriteString("Git Diff:\n" + diff + "\n\n")
+
+	b.WriteString(`Guidelines:
+- Title: one line, under 72 characters, imperative mood, no trailing period
+- Description: Markdown explaining what changed and why, for a reviewer
+  who has not seen the branch
+- Mention anything reviewers should check, and any follow-up work
+- Do not invent issue numbers, links or test results
+`)
+	if template := cleanPRTemplate(input.Template); template != "" {
+		b.WriteString(`- The repository has a pull request template. The description must use
+  its sections, in the same order and with the same headings. Fill in each
+  section from the changes; leave checklist boxes unchecked unless the
+  change clearly satisfies them, and write "N/A" for sections that do not
+  apply.
+
+Pull Request Template:
+` + template + "\n")
+	}
+
+	b.WriteString(`
+Format your response as follows:
+Title: [pull request title]
+
+Description:
+[Markdown description]`)
+	return b.String()
+}
+
+// formatPRCommits lists commit subjects with the start of each body, capping
+// the total so long branches do not crowd out the diff.
+func formatPRCommits(messages []string) string {
+	shown := messages
+	if len(shown) > maxPRCommits {
+		shown = shown[len(shown)-maxPRCommits:]
+	}
+	lines := make([]string, 0, len(shown)*2)
+	if omitted := len(messages) - len(shown); omitted > 0 {
+		lines = append(lines, fmt.Sprintf("(%d earlier commits omitted)", omitted))
+	}
+	for _, message := range shown {
+		subject, body := splitSubjectBody(message)
+		lines = append(lines, "- "+subject)
+		if body == "" {
+			continue
+		}
+		bodyLines := strings.Split(body, "\n")
+		if len(bodyLines) > maxPRCommitBodyLines {
+			bodyLines = append(bodyLines[:maxPRCommitBodyLines], "...")
+		}
+		for _, line := range bodyLines {
+			lines = append(lines, strings.TrimRight("  "+line, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cleanPRTemplate drops HTML comments, which templates use for instructions
+// aimed at the author rather than content for the description.
+func cleanPRTemplate(template string) string {
+	template = templateCommentExpr.ReplaceAllString(template, "")
+	lines := strings.Split(strings.ReplaceAll(template, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && len(out) > 0 && out[len(out)-1] == "" {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func extractPullRequest(response string) (PullRequest, error) {
+	cleaned := strings.TrimSpace(response)
+	if cleaned == "" {
+		return PullRequest{}, fmt.Errorf("model returned empty pull request output")
+	}
+	if strings.HasPrefix(cleaned, "```") && strings.HasSuffix(cleaned, "```") {
+		cleaned = strings.TrimSpace(strings.TrimSuffix(cleaned, "```"))
+		if _, rest, ok := strings.Cut(cleaned, "\n"); ok {
+			cleaned = rest
+		}
+	}
+
+	var title, body string
+	lines := strings.Split(cleaned, "\n")
+	titleLine := -1
+	for i, line := range lines {
+		trimmed := strings.Trim(strings.TrimSpace(line), "*#")
+		trimmed = strings.TrimSpace(trimmed)
+		if strings.HasPrefix(strings.ToLower(trimmed), "title:") {
+			title = strings.TrimSpace(trimmed[len("title:"):])
+			titleLine = i
+			break
+		}
+	}
+	if titleLine < 0 {
+		title, body = splitSubjectBody(cleaned)
+	} else {
+		body = strings.Join(lines[titleLine+1:], "\n")
+	}
+	body = strings.TrimSpace(body)
+	for _, marker := range []string{"Description:", "**Description:**", "## Description:"} {
+		if strings.HasPrefix(body, marker) {
+			body = strings.TrimSpace(body[len(marker):])
+			break
+		}
+	}
+
+	title = strings.Trim(strings.Trim(strings.TrimSpace(title), "*"), `"'`+"`")
+	title = strings.TrimSpace(strings.TrimPrefix(title, "#"))
+	if title == "" || !containsLetter(title) {
+		return PullRequest{}, fmt.Errorf("model response did not contain a pull request title")
+	}
+	return PullRequest{Title: title, Body: body}, nil
+}
+
+// shortenTitle cuts title to at most max characters at a word boundary and
+// reports whether it had to.
+func shortenTitle(title string, max int) (string, bool) {
+	runes := []rune(title)
+	if len(runes) <= max {
+		return title, false
+	}
+	cut := string(runes[:max])
+	// Unless the cut already falls between words, drop the partial word.
+	if !unicode.IsSpace(runes[max]) {
+		if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " ,;:-–—"), true
+}
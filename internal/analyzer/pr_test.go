@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractPullRequestMarkers(t *testing.T) {
+	response := "**Title:** Add pull request generation\n\nDescription:\n## Summary\nAdds `gitcomm pr`.\n\n## Testing\n- [ ] go test ./..."
+	pr, err := extractPullRequest(response)
+	if err != nil {
+		t.Fatalf("extractPullRequest() error = %v", err)
+	}
+	if pr.Title != "Add pull request generation" {
+		t.Fatalf("unexpected title %q", pr.Title)
+	}
+	want := "## Summary\nAdds `gitcomm pr`.\n\n## Testing\n- [ ] go test ./..."
+	if pr.Body != want {
+		t.Fatalf("unexpected body:\n%s", pr.Body)
+	}
+	if pr.String() != pr.Title+"\n\n"+want {
+		t.Fatalf("unexpected String():\n%s", pr.String())
+	}
+}
+
+func TestExtractPullRequestWithoutMarkers(t *testing.T) {
+	pr, err := extractPullRequest("```markdown\nFix login redirect\n\nThe redirect lost the query string.\n```")
+	if err != nil {
+		t.Fatalf("extractPullRequest() error = %v", err)
+	}
+	if pr.Title != "Fix login redirect" || pr.Body != "The redirect lost the query string." {
+		t.Fatalf("unexpected pull request %+v", pr)
+	}
+	if _, err := extractPullRequest("Title: ---"); err == nil {
+		t.Fatal("expected an error for a title without letters")
+	}
+}
+
+func TestShortenTitleCutsAtAWordBoundary(t *testing.T) {
+	long := "Add pull request generation with templates, JSON output and a base branch that is detected from the remote"
+	pr, err := extractPullRequest("Title: " + long + "\n\nBody")
+	if err != nil || pr.Title != long {
+		t.Fatalf("a long title should not fail parsing: %+v, %v", pr, err)
+	}
+	title, shortened := shortenTitle(long, maxPRTitleLength)
+	if !shortened || title != "Add pull request generation with templates, JSON output and a base branch that is detected from the" {
+		t.Fatalf("shortenTitle() = %q, %v", title, shortened)
+	}
+	if title, shortened := shortenTitle("Fix login, redirect", 11); !shortened || title != "Fix login" {
+		t.Fatalf("shortenTitle() = %q, %v", title, shortened)
+	}
+	if title, shortened := shortenTitle("Fix login", 9); shortened || title != "Fix login" {
+		t.Fatalf("a short title should be kept, got %q", title)
+	}
+}
+
+func TestBuildPRPromptFollowsTemplate(t *testing.T) {
+	input := PRInput{
+		Branch:   "feature/pr",
+		Base:     "main",
+		Commits:  []string{"Add pr command\n\nWires up the subcommand.", "Fix typo"},
+		Template: "## What\n<!-- describe the change -->\n\n\n## Checklist\n- [ ] Tests added\r\n",
+	}
+	prompt := buildPRPrompt(input, "", "diff --git a/a b/a")
+	for _, want := range []string{
+		`branch "feature/pr" into "main"`,
+		"- Add pr command\n  Wires up the subcommand.\n- Fix typo",
+		"Pull Request Template:\n## What\n\n## Checklist\n- [ ] Tests added\n",
+		"Title: [pull request title]",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "describe the change") {
+		t.Fatal("template comments should be stripped")
+	}
+
+	input.Template = ""
+	if strings.Contains(buildPRPrompt(input, "", "diff"), "Pull Request Template") {
+		t.Fatal("prompt should not mention a template when none exists")
+	}
+}
+
+func TestFormatPRCommitsCapsLongBranches(t *testing.T) {
+	messages := make([]string, maxPRCommits+3)
+	for i := range messages {
+		messages[i] = "Commit"
+	}
+	got := formatPRCommits(messages)
+	if !strings.HasPrefix(got, "(3 earlier commits omitted)\n") {
+		t.Fatalf("expected omission note, got %q", got[:40])
+	}
+	if n := strings.Count(got, "- Commit"); n != maxPRCommits {
+		t.Fatalf("expected %d commits, got %d", maxPRCommits, n)
+	}
+}
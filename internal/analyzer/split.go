@@ -0,0 +1,225 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/llm"
+)
+
+const (
+	splitMaxTokens     = 1500
+	maxSplitHunkLines  = 40
+	maxSplitPromptDiff = 24000
+)
+
+var (
+	splitCommitExpr = regexp.MustCompile(`(?i)^\s*(?:\*\*)?commit(?:\s+\d+)?(?:\*\*)?\s*:(?:\*\*)?\s*(?:hunks?\s*)?([\d,\s]*)$`)
+	splitIDExpr     = regexp.MustCompile(`\d+`)
+)
+
+// SplitHunk is one numbered piece of the staged diff offered to the model.
+type SplitHunk struct {
+	ID   int
+	Path string
+	Diff string
+	// Label is a one-line description for the plan legend.
+	Label string
+}
+
+// SplitGroup is one planned commit: the hunks it takes and its message.
+type SplitGroup struct {
+	Hunks   []int
+	Message string
+}
+
+// ProposeSplit asks the model to group the hunks into logical commits.
+func ProposeSplit(hunks []SplitHunk) ([]SplitGroup, error) {
+	fmt.Println("🤖 Proposing how to split the staged changes...")
+	client, err := llm.NewClient(llm.ClientConfig{MaxTokens: splitMaxTokens, Temperature: 0.3})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	prompt := buildSplitPrompt(hunks)
+	diag.Info("analyzer", "built split prompt", "hunks", len(hunks), "prompt_chars", len(prompt))
+	response, err := client.SendPrompt(prompt)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := ParseSplitPlan(response)
+	if err != nil {
+		diag.Error("analyzer", "failed to parse split plan", "error", err, "response_snippet", diag.Snippet(response, 300))
+		return nil, err
+	}
+	diag.Info("analyzer", "parsed split plan", "groups", len(groups))
+	return groups, nil
+}
+
+func buildSplitPrompt(hunks []SplitHunk) string {
+	var b strings.Builder
+	b.WriteString(`The following staged changes mix unrelated work. Group the numbered hunks
+into a sequence of logical commits, each of which makes sense on its own.
+
+Hunks:
+`)
+	budget := maxSplitPromptDiff
+	for _, hunk := range hunks {
+		diff := hunk.Diff
+		lines := strings.Split(diff, "\n")
+		if len(lines) > maxSplitHunkLines {
+			diff = strings.Join(lines[:maxSplitHunkLines], "\n") + fmt.Sprintf("\n[[gitcomm: %d more lines omitted]]", len(lines)-maxSplitHunkLines)
+		}
+		if budget-len(diff) < 0 {
+			diff = "[[gitcomm: hunk content omitted to fit the prompt]]"
+		}
+		budget -= len(diff)
+		fmt.Fprintf(&b, "\n=== Hunk %d: %s\n%s\n", hunk.ID, hunk.Path, diff)
+	}
+
+	b.WriteString(`
+Rules:
+- Every hunk number must appear in exactly one commit
+- Order commits so each builds on the previous ones
+- Keep related hunks together, even across files
+- Give every commit a proper Git commit message: a subject line of 50-72
+  characters, a blank line, then a body wrapped at 72 characters explaining
+  what changed and why
+
+Format your response exactly like this, with no other text:
+commit: 1, 4
+Subject of the first commit
+
+Body of the first commit.
+
+commit: 2, 3
+Subject of the second commit
+
+Body of the second commit.`)
+	return b.String()
+}
+
+// ParseSplitPlan reads a plan made of "commit: <hunk numbers>" lines, each
+// followed by that commit's message. Lines starting with "#" are comments.
+func ParseSplitPlan(text string) ([]SplitGroup, error) {
+	var groups []SplitGroup
+	var message []string
+	flush := func() error {
+		if len(groups) == 0 {
+			return nil
+		}
+		last := &groups[len(groups)-1]
+		normalized, err := normalizeCommitMessage(strings.Join(message, "\n"))
+		if err != nil {
+			return fmt.Errorf("commit %d: %w", len(groups), err)
+		}
+		last.Message = normalized
+		message = message[:0]
+		return nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		if m := splitCommitExpr.FindStringSubmatch(line); m != nil {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			var ids []int
+			for _, id := range splitIDExpr.FindAllString(m[1], -1) {
+				n, _ := strconv.Atoi(id)
+				ids = append(ids, n)
+			}
+			groups = append(groups, SplitGroup{Hunks: ids})
+			continue
+		}
+		if len(groups) > 0 {
+			message = append(message, line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("plan does not contain any \"commit:\" lines")
+	}
+	return groups, nil
+}
+
+// ValidateSplitPlan checks that every hunk in ids is assigned to exactly one
+// commit and that no commit is empty.
+func ValidateSplitPlan(groups []SplitGroup, ids []int) error {
+	known := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
+	}
+	owner := make(map[int]int, len(ids))
+	for i, group := range groups {
+		if len(group.Hunks) == 0 {
+			return fmt.Errorf("commit %d has no hunks", i+1)
+		}
+		for _, id := range group.Hunks {
+			if !known[id] {
+				return fmt.Errorf("commit %d refers to unknown hunk %d", i+1, id)
+			}
+			if prev, ok := owner[id]; ok {
+				return fmt.Errorf("hunk %d is assigned to both commit %d and commit %d", id, prev, i+1)
+			}
+			owner[id] = i + 1
+		}
+	}
+	var missing []string
+	for _, id := range ids {
+		if _, ok := owner[id]; !ok {
+			missing = append(missing, strconv.Itoa(id))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s not assigned to any commit: %s", pluralHunks(len(missing)), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// FormatSplitPlan renders groups in the format ParseSplitPlan reads, followed
+// by commented-out instructions and a legend of the hunks.
+func FormatSplitPlan(groups []SplitGroup, hunks []SplitHunk) string {
+	var b strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		ids := append([]int(nil), group.Hunks...)
+		sort.Ints(ids)
+		parts := make([]string, len(ids))
+		for j, id := range ids {
+			parts[j] = strconv.Itoa(id)
+		}
+		fmt.Fprintf(&b, "commit: %s\n%s\n", strings.Join(parts, ", "), group.Message)
+	}
+	b.WriteString(`
+# Each "commit:" line starts a commit and lists the hunks it takes; the
+# lines after it, up to the next "commit:" line, are its message.
+# Commits are created from top to bottom. Every hunk must be used exactly
+# once. Reorder, merge or split commits and edit messages as you like.
+# Lines starting with "#" are ignored. Delete everything to abort.
+#
+# Hunks:
+`)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&b, "#   %d  %s\n", hunk.ID, hunk.Label)
+	}
+	return b.String()
+}
+
+func pluralHunks(n int) string {
+	if n == 1 {
+		return "hunk"
+	}
+	return "hunks"
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSplitPlanReadsGroupsAndMessages(t *testing.T) {
+	plan := "Here is the plan:\n\n**Commit 1:** hunks 1, 3\nAdd retry support\n\nRetries failed requests.\nCommit hooks: unaffected.\n\ncommit: 2\n# a comment\nFix typo in README\n"
+	groups, err := ParseSplitPlan(plan)
+	if err != nil {
+		t.Fatalf("ParseSplitPlan() error = %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if got := groups[0].Hunks; len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("unexpected hunks %v", got)
+	}
+	if groups[0].Message != "Add retry support\n\nRetries failed requests.\nCommit hooks: unaffected." {
+		t.Fatalf("unexpected message %q", groups[0].Message)
+	}
+	if groups[1].Message != "Fix typo in README" {
+		t.Fatalf("unexpected message %q", groups[1].Message)
+	}
+	if _, err := ParseSplitPlan("no plan here"); err == nil {
+		t.Fatal("expected an error without commit lines")
+	}
+}
+
+func TestValidateSplitPlan(t *testing.T) {
+	ids := []int{1, 2, 3}
+	cases := map[string][]SplitGroup{
+		"":                      {{Hunks: []int{1, 3}}, {Hunks: []int{2}}},
+		"unknown hunk 4":        {{Hunks: []int{1, 2, 3, 4}}},
+		"both commit 1 and":     {{Hunks: []int{1, 2}}, {Hunks: []int{2, 3}}},
+		"hunk not assigned":     {{Hunks: []int{1, 2}}},
+		"commit 2 has no hunks": {{Hunks: []int{1, 2, 3}}, {}},
+	}
+	for want, groups := range cases {
+		err := ValidateSplitPlan(groups, ids)
+		if want == "" {
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("ValidateSplitPlan() = %v, want error containing %q", err, want)
+		}
+	}
+}
+
+func TestFormatSplitPlanRoundTrips(t *testing.T) {
+	groups := []SplitGroup{{Hunks: []int{3, 1}, Message: "Add feature\n\nBody."}, {Hunks: []int{2}, Message: "Fix bug"}}
+	hunks := []SplitHunk{{ID: 1, Label: "a.go lines 1-4"}, {ID: 2, Label: "b.go (new file, +3 -0)"}, {ID: 3, Label: "a.go lines 40-44"}}
+	plan := FormatSplitPlan(groups, hunks)
+	if !strings.Contains(plan, "#   2  b.go (new file, +3 -0)") {
+		t.Fatalf("missing legend:\n%s", plan)
+	}
+	parsed, err := ParseSplitPlan(plan)
+	if err != nil {
+		t.Fatalf("ParseSplitPlan() error = %v", err)
+	}
+	if len(parsed) != 2 || parsed[0].Message != "Add feature\n\nBody." || parsed[0].Hunks[0] != 1 || parsed[1].Message != "Fix bug" {
+		t.Fatalf("round trip mismatch: %+v", parsed)
+	}
+}
@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// styleThreshold is the share of sampled commits that must follow a
+	// convention before it is passed on as guidance.
+	styleThreshold       = 0.6
+	minStyleSamples      = 3
+	maxStyleExampleLines = 6
+	maxStyleTypes        = 6
+)
+
+var (
+	conventionalSubjectExpr = regexp.MustCompile(`^([a-z]+)(\([^)]+\))?!?: \S`)
+	bracketPrefixExpr       = regexp.MustCompile(`^\[[^\]]+\]\s+`)
+	areaPrefixExpr          = regexp.MustCompile(`^[A-Za-z0-9_./-]+: \S`)
+)
+
+// commitStyle is what detectCommitStyle learned from a repository's history.
+type commitStyle struct {
+	samples      int
+	conventional bool
+	types        []string
+	scopes       bool
+	// prefix describes a non-conventional subject prefix, if one is common.
+	prefix string
+	// capitalized is 1 for "Capitalized", -1 for "lowercase", 0 for mixed.
+	capitalized   int
+	subjectLength int
+	// bodies is 1 when most commits have a body, -1 when few do.
+	bodies int
+	// periods is 1 when subjects end with a period, -1 when they do not.
+	periods  int
+	examples []string
+}
+
+// detectCommitStyle looks at recent commit messages, newest first, and
+// records the conventions most of them share. Merges, reverts and
+// fixup/squash commits are left out since their subjects are generated.
+func detectCommitStyle(messages []string, examples int) commitStyle {
+	var style commitStyle
+	types := map[string]int{}
+	var conventional, scoped, bracket, area, upper, lower, withBody, period int
+	var lengths []int
+	for _, message := range messages {
+		subject, body := splitSubjectBody(strings.TrimSpace(message))
+		if subject == "" || isGeneratedSubject(subject) {
+			continue
+		}
+		style.samples++
+		if len(style.examples) < examples {
+			style.examples = append(style.examples, styleExample(subject, body))
+		}
+		lengths = append(lengths, utf8.RuneCountInString(subject))
+		if body != "" {
+			withBody++
+		}
+		if strings.HasSuffix(subject, ".") {
+			period++
+		}
+
+		description := subject
+		if m := conventionalSubjectExpr.FindStringSubmatch(subject); m != nil {
+			conventional++
+			types[m[1]]++
+			if m[2] != "" {
+				scoped++
+			}
+			description = subject[strings.Index(subject, ": ")+2:]
+		} else if loc := bracketPrefixExpr.FindStringIndex(subject); loc != nil {
+			bracket++
+			description = subject[loc[1]:]
+		} else if areaPrefixExpr.MatchString(subject) {
+			area++
+			description = subject[strings.Index(subject, ": ")+2:]
+		}
+		if r, _ := utf8.DecodeRuneInString(description); unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	if style.samples == 0 {
+		return style
+	}
+
+	share := func(n int) float64 { return float64(n) / float64(style.samples) }
+	if share(conventional) >= styleThreshold {
+		style.conventional = true
+		style.scopes = float64(scoped)/float64(conventional) >= 0.5
+		for name := range types {
+			style.types = append(style.types, name)
+		}
+		sort.Slice(style.types, func(i, j int) bool {
+			if types[style.types[i]] != types[style.types[j]] {
+				return types[style.types[i]] > types[style.types[j]]
+			}
+			return style.types[i] < style.types[j]
+		})
+		if len(style.types) > maxStyleTypes {
+			style.types = style.types[:maxStyleTypes]
+		}
+	} else if share(bracket) >= styleThreshold {
+		style.prefix = `a bracketed tag, e.g. "[area] Subject"`
+	} else if share(area) >= styleThreshold {
+		style.prefix = `the affected area and a colon, e.g. "parser: subject"`
+	}
+	switch {
+	case share(upper) >= styleThreshold:
+		style.capitalized = 1
+	case share(lower) >= styleThreshold:
+		style.capitalized = -1
+	}
+	switch {
+	case share(withBody) >= styleThreshold:
+		style.bodies = 1
+	case share(withBody) <= 1-styleThreshold:
+		style.bodies = -1
+	}
+	switch {
+	case share(period) >= styleThreshold:
+		style.periods = 1
+	case share(period) <= 1-styleThreshold:
+		style.periods = -1
+	}
+	sort.Ints(lengths)
+	style.subjectLength = lengths[len(lengths)/2]
+	return style
+}
+
+func isGeneratedSubject(subject string) bool {
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func styleExample(subject, body string) string {
+	lines := []string{subject}
+	if body != "" {
+		bodyLines := strings.Split(body, "\n")
+		if len(bodyLines) > maxStyleExampleLines-1 {
+			bodyLines = append(bodyLines[:maxStyleExampleLines-1], "...")
+		}
+		lines = append(append(lines, ""), bodyLines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// guidance renders the style as a prompt section, or "" when there were too
+// few commits to learn from.
+func (s commitStyle) guidance() string {
+	if s.samples < minStyleSamples {
+		return ""
+	}
+	var rules []string
+	if s.conventional {
+		format := "type: description"
+		if s.scopes {
+			format = "type(scope): description"
+		}
+		rules = append(rules, fmt.Sprintf("Use Conventional Commits (%q); types used here: %s", format, strings.Join(s.types, ", ")))
+	} else if s.prefix != "" {
+		rules = append(rules, "Start the subject with "+s.prefix)
+	}
+	switch s.capitalized {
+	case 1:
+		rules = append(rules, "Start the subject description with a capital letter")
+	case -1:
+		rules = append(rules, "Start the subject description with a lowercase letter")
+	}
+	rules = append(rules, fmt.Sprintf("Subjects here are typically about %d characters long", s.subjectLength))
+	switch s.bodies {
+	case 1:
+		rules = append(rules, "Most commits have a body explaining the change")
+	case -1:
+		rules = append(rules, "Most commits are a subject line only; add a short body only when the change needs explaining")
+	}
+	switch s.periods {
+	case 1:
+		rules = append(rules, "End the subject with a period")
+	case -1:
+		rules = append(rules, "Do not end the subject with a period")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Repository commit style (learned from %d recent commits; where it differs from the practices above, follow the repository):\n", s.samples)
+	for _, rule := range rules {
+		b.WriteString("- " + rule + "\n")
+	}
+	if len(s.examples) > 0 {
+		b.WriteString("\nRecent commit messages from this repository, as examples of the style only (do not reuse their content):\n")
+		for _, example := range s.examples {
+			b.WriteString("---\n" + example + "\n")
+		}
+		b.WriteString("---\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
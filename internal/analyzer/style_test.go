@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectCommitStyleConventional(t *testing.T) {
+	messages := []string{
+		"feat(llm): add key rotation\n\nRotate through the configured keys.",
+		"fix(git): handle unborn HEAD",
+		"Merge branch 'main' into feature",
+		"fix: trim whitespace from model names",
+		"docs(readme): describe split",
+		"fixup! fix(git): handle unborn HEAD",
+		"feat(hook): install prepare-commit-msg hook",
+	}
+	style := detectCommitStyle(messages, 2)
+	if style.samples != 5 {
+		t.Fatalf("expected merges and fixups to be skipped, got %d samples", style.samples)
+	}
+	if !style.conventional || !style.scopes || style.capitalized != -1 || style.bodies != -1 || style.periods != -1 {
+		t.Fatalf("unexpected style: %+v", style)
+	}
+	if strings.Join(style.types, ",") != "feat,fix,docs" {
+		t.Fatalf("types should be ordered by frequency, got %v", style.types)
+	}
+
+	guidance := style.guidance()
+	for _, want := range []string{
+		`Use Conventional Commits ("type(scope): description"); types used here: feat, fix, docs`,
+		"Start the subject description with a lowercase letter",
+		"Most commits are a subject line only",
+		"Do not end the subject with a period",
+		"---\nfeat(llm): add key rotation\n\nRotate through the configured keys.\n---\nfix(git): handle unborn HEAD\n---",
+	} {
+		if !strings.Contains(guidance, want) {
+			t.Fatalf("guidance missing %q:\n%s", want, guidance)
+		}
+	}
+	if strings.Contains(guidance, "docs(readme)") {
+		t.Fatalf("only the configured number of examples should be quoted:\n%s", guidance)
+	}
+}
+
+func TestDetectCommitStylePrefixesAndBodies(t *testing.T) {
+	messages := []string{
+		"[user-1] Add parser\n\nExplain.",
+		"[user-2] Fix lexer\n\nExplain.",
+		"[user-3] Update docs\n\nExplain.",
+		"Tidy up",
+	}
+	style := detectCommitStyle(messages, 0)
+	if style.conventional || !strings.Contains(style.prefix, "bracketed") || style.capitalized != 1 || style.bodies != 1 {
+		t.Fatalf("unexpected style: %+v", style)
+	}
+	if guidance := style.guidance(); strings.Contains(guidance, "Recent commit messages") {
+		t.Fatalf("no examples were requested:\n%s", guidance)
+	}
+}
+
+func TestCommitStyleNeedsEnoughSamples(t *testing.T) {
+	if got := detectCommitStyle([]string{"Initial commit", "Add README"}, 5).guidance(); got != "" {
+		t.Fatalf("expected no guidance from two commits, got:\n%s", got)
+	}
+	if prompt := buildPrompt("", "diff", ""); strings.Contains(prompt, "Repository commit style") {
+		t.Fatal("prompt should not mention style without guidance")
+	}
+	if prompt := buildPrompt("", "diff", "Repository commit style (learned from 3 recent commits)"); !strings.Contains(prompt, "commits)\n\nFormat your response") {
+		t.Fatalf("style guidance should precede the response format:\n%s", prompt)
+	}
+}
@@ -0,0 +1,32 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+)
+
+// submoduleGuidance explains submodule changes, which the diff only shows as
+// "Subproject commit" lines, or returns "" when there are none.
+func submoduleGuidance(submodules []gitdiff.Submodule) string {
+	if len(submodules) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Submodule changes (the diff only shows their commit ids):\n")
+	for _, sub := range submodules {
+		b.WriteString("- " + sub.Summary() + "\n")
+		if len(sub.Log) > 0 {
+			b.WriteString("  commits brought in:\n")
+			for _, line := range sub.Log {
+				b.WriteString("    " + line + "\n")
+			}
+			if sub.LogOmitted > 0 {
+				fmt.Fprintf(&b, "    ... and %d more\n", sub.LogOmitted)
+			}
+		}
+	}
+	b.WriteString(`Describe a submodule update as "Bump <path> from <old> to <new>" rather than "update submodule", and summarize the commits it brings in when they are listed.`)
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+)
+
+func TestSubmoduleGuidance(t *testing.T) {
+	if got := submoduleGuidance(nil); got != "" {
+		t.Fatalf("expected no guidance without submodules, got %q", got)
+	}
+	got := submoduleGuidance([]gitdiff.Submodule{
+		{Path: "lib", Old: "1111111aaaa", New: "2222222bbbb", Log: []string{"2222222 Fix lexer", "abcdef0 Add parser"}, LogOmitted: 3},
+		{Path: "docs", New: "3333333cccc"},
+	})
+	for _, want := range []string{
+		"- bump lib from 1111111 to 2222222\n  commits brought in:\n    2222222 Fix lexer\n    abcdef0 Add parser\n    ... and 3 more\n",
+		"- add submodule docs at 3333333\n",
+		`"Bump <path> from <old> to <new>"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("guidance missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/symbols"
+)
+
+// maxPromptSymbols caps the changed symbols listed in the prompt.
+const maxPromptSymbols = 40
+
+// symbolGuidance lists the functions, methods and types the change adds,
+// removes or modifies, grouped by file, or returns "" when there are none.
+func symbolGuidance(changes []symbols.Change) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	var (
+		paths  []string
+		byPath = make(map[string][]string)
+	)
+	for i, change := range changes {
+		if i == maxPromptSymbols {
+			break
+		}
+		if _, ok := byPath[change.Path]; !ok {
+			paths = append(paths, change.Path)
+		}
+		byPath[change.Path] = append(byPath[change.Path], change.String())
+	}
+
+	var b strings.Builder
+	b.WriteString("Changed symbols (from the declarations in the old and new version of each file):\n")
+	for _, path := range paths {
+		b.WriteString("- " + path + ": " + strings.Join(byPath[path], ", ") + "\n")
+	}
+	if len(changes) > maxPromptSymbols {
+		fmt.Fprintf(&b, "- ... and %d more\n", len(changes)-maxPromptSymbols)
+	}
+	b.WriteString("Name the functions, methods or types that changed in the subject and body where it helps, rather than only the files they are in.")
+	return b.String()
+}
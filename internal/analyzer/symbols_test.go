@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/symbols"
+)
+
+func TestSymbolGuidance(t *testing.T) {
+	if got := symbolGuidance(nil); got != "" {
+		t.Fatalf("expected no guidance without symbols, got %q", got)
+	}
+	got := symbolGuidance([]symbols.Change{
+		{Path: "git/git.go", Kind: "method", Name: "Repo.ChangesFromPatch", Status: symbols.Added},
+		{Path: "main.go", Kind: "func", Name: "main", Status: symbols.Modified},
+		{Path: "git/git.go", Kind: "func", Name: "prepare", Status: symbols.Removed},
+	})
+	for _, want := range []string{
+		"- git/git.go: added method Repo.ChangesFromPatch, removed func prepare\n",
+		"- main.go: modified func main\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("guidance missing %q:\n%s", want, got)
+		}
+	}
+
+	many := make([]symbols.Change, maxPromptSymbols+5)
+	for i := range many {
+		many[i] = symbols.Change{Path: "a.go", Kind: "func", Name: fmt.Sprintf("F%d", i), Status: symbols.Added}
+	}
+	if got := symbolGuidance(many); !strings.Contains(got, "... and 5 more") || strings.Contains(got, fmt.Sprintf("F%d", maxPromptSymbols)) {
+		t.Fatalf("expected the list to be capped:\n%s", got)
+	}
+}
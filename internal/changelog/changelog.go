@@ -0,0 +1,232 @@
+// Package changelog parses grouped release notes and maintains a CHANGELOG.md
+// in Keep a Changelog format (https://keepachangelog.com/en/1.1.0/).
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const FileName = "CHANGELOG.md"
+
+// Unreleased is the version heading used for changes not yet tagged.
+const Unreleased = "Unreleased"
+
+// Sections lists the headings release notes are grouped under, in the order
+// they are rendered. Breaking changes come first so they are hard to miss;
+// the rest are the Keep a Changelog change types.
+var Sections = []string{"Breaking Changes", "Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+const header = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+var (
+	headingExpr = regexp.MustCompile(`^\s*(?:#{1,4}\s*)?\**([A-Za-z][A-Za-z ]*?)\**\s*:?\s*\**\s*$`)
+	bulletExpr  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+)
+
+// sectionAliases maps the names models tend to use onto Sections.
+var sectionAliases = map[string]string{
+	"breaking changes": "Breaking Changes",
+	"breaking":         "Breaking Changes",
+	"breaking change":  "Breaking Changes",
+	"added":            "Added",
+	"features":         "Added",
+	"new features":     "Added",
+	"changed":          "Changed",
+	"changes":          "Changed",
+	"improvements":     "Changed",
+	"deprecated":       "Deprecated",
+	"removed":          "Removed",
+	"fixed":            "Fixed",
+	"fixes":            "Fixed",
+	"bug fixes":        "Fixed",
+	"security":         "Security",
+}
+
+// Notes holds release-note bullets grouped by section name.
+type Notes struct {
+	Sections map[string][]string
+}
+
+func (n Notes) Empty() bool {
+	for _, entries := range n.Sections {
+		if len(entries) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseNotes reads Markdown grouped under section headings such as
+// "### Added" or "Fixes:". Bullets before the first recognized heading and
+// under unknown headings are dropped.
+func ParseNotes(text string) Notes {
+	notes := Notes{Sections: make(map[string][]string)}
+	current := ""
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if m := bulletExpr.FindStringSubmatch(line); m != nil {
+			if current != "" && strings.TrimSpace(m[1]) != "" {
+				notes.Sections[current] = append(notes.Sections[current], strings.TrimSpace(m[1]))
+			}
+			continue
+		}
+		if m := headingExpr.FindStringSubmatch(line); m != nil {
+			section, known := sectionAliases[strings.ToLower(strings.TrimSpace(m[1]))]
+			if known || strings.HasPrefix(strings.TrimSpace(line), "#") {
+				current = section
+				continue
+			}
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || current == "" {
+			continue
+		}
+		// Wrapped continuation of the previous bullet.
+		if entries := notes.Sections[current]; len(entries) > 0 && strings.HasPrefix(line, " ") {
+			entries[len(entries)-1] += " " + trimmed
+		}
+	}
+	return notes
+}
+
+// Merge appends other's entries, skipping exact duplicates.
+func (n *Notes) Merge(other Notes) {
+	if n.Sections == nil {
+		n.Sections = make(map[string][]string)
+	}
+	for section, entries := range other.Sections {
+		seen := make(map[string]bool, len(n.Sections[section]))
+		for _, entry := range n.Sections[section] {
+			seen[entry] = true
+		}
+		for _, entry := range entries {
+			if !seen[entry] {
+				n.Sections[section] = append(n.Sections[section], entry)
+				seen[entry] = true
+			}
+		}
+	}
+}
+
+// Markdown renders the non-empty sections as "### Section" blocks.
+func (n Notes) Markdown() string {
+	var blocks []string
+	for _, section := range Sections {
+		entries := n.Sections[section]
+		if len(entries) == 0 {
+			continue
+		}
+		lines := []string{"### " + section, ""}
+		for _, entry := range entries {
+			lines = append(lines, "- "+entry)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// Heading returns the release heading, e.g. "## [v1.2.0] - 2024-05-01" or
+// "## [Unreleased]".
+func Heading(version, date string) string {
+	if version == "" || strings.EqualFold(version, Unreleased) {
+		return "## [" + Unreleased + "]"
+	}
+	if date == "" {
+		return fmt.Sprintf("## [%s]", version)
+	}
+	return fmt.Sprintf("## [%s] - %s", version, date)
+}
+
+// Update inserts a release section into an existing changelog, replacing a
+// section with the same version if present and otherwise adding it above the
+// newest release. An empty changelog gets the standard header first. When a
+// version is released, the entries under "## [Unreleased]" are folded into
+// it and the Unreleased heading is left empty above it.
+func Update(existing, version, date string, notes Notes) string {
+	if strings.TrimSpace(existing) == "" {
+		return header + "\n" + Heading(version, date) + "\n\n" + notes.Markdown() + "\n"
+	}
+
+	lines := strings.Split(strings.ReplaceAll(existing, "\r\n", "\n"), "\n")
+	if version == "" {
+		version = Unreleased
+	}
+	if !strings.EqualFold(version, Unreleased) {
+		for i, line := range lines {
+			if !isVersionHeading(line, Unreleased) {
+				continue
+			}
+			end := sectionEnd(lines, i)
+			folded := ParseNotes(strings.Join(lines[i+1:end], "\n"))
+			folded.Merge(notes)
+			notes = folded
+			lines = append(append(lines[:i+1:i+1], ""), lines[end:]...)
+			break
+		}
+	}
+	section := Heading(version, date) + "\n\n" + notes.Markdown() + "\n"
+
+	insertAt := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+		if isVersionHeading(line, version) {
+			rest := strings.Join(lines[sectionEnd(lines, i):], "\n")
+			return joinSections(strings.Join(lines[:i], "\n"), section, rest)
+		}
+		// New releases go below Unreleased, which always stays on top.
+		if insertAt < 0 && !isVersionHeading(line, Unreleased) {
+			insertAt = i
+		}
+	}
+	if insertAt < 0 {
+		for i, line := range lines {
+			if isLinkReference(line) {
+				insertAt = i
+				break
+			}
+		}
+	}
+	if insertAt < 0 {
+		return joinSections(strings.Join(lines, "\n"), section, "")
+	}
+	return joinSections(strings.Join(lines[:insertAt], "\n"), section, strings.Join(lines[insertAt:], "\n"))
+}
+
+// isVersionHeading reports whether line is the "## [version]" heading.
+func isVersionHeading(line, version string) bool {
+	return strings.HasPrefix(strings.ToLower(line), strings.ToLower("## ["+version+"]"))
+}
+
+// sectionEnd returns the index of the line after the release section whose
+// heading is lines[i]: the next heading, the link references or the end.
+func sectionEnd(lines []string, i int) int {
+	for j := i + 1; j < len(lines); j++ {
+		if strings.HasPrefix(lines[j], "## ") || isLinkReference(lines[j]) {
+			return j
+		}
+	}
+	return len(lines)
+}
+
+func joinSections(before, section, after string) string {
+	parts := []string{strings.TrimRight(before, "\n"), strings.TrimRight(section, "\n")}
+	if strings.TrimSpace(after) != "" {
+		parts = append(parts, strings.TrimRight(after, "\n"))
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// isLinkReference matches the "[1.0.0]: https://..." compare links Keep a
+// Changelog puts at the bottom of the file.
+func isLinkReference(line string) bool {
+	return strings.HasPrefix(line, "[") && strings.Contains(line, "]: ")
+}
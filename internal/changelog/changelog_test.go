@@ -0,0 +1,91 @@
+package changelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNotesNormalizesHeadings(t *testing.T) {
+	notes := ParseNotes(`Here are the release notes:
+
+## Features
+- Add changelog command
+- Support Keep a Changelog
+  files at any path
+
+**Bug Fixes:**
+* Fix crash on empty range
+
+### Internal
+- Refactor tests
+
+BREAKING CHANGES
+- Drop Go 1.20 support
+`)
+	want := map[string][]string{
+		"Added":            {"Add changelog command", "Support Keep a Changelog files at any path"},
+		"Fixed":            {"Fix crash on empty range"},
+		"Breaking Changes": {"Drop Go 1.20 support"},
+	}
+	if !reflect.DeepEqual(notes.Sections, want) {
+		t.Fatalf("ParseNotes() = %#v\nwant %#v", notes.Sections, want)
+	}
+}
+
+func TestMergeSkipsDuplicatesAndRendersInOrder(t *testing.T) {
+	notes := ParseNotes("### Fixed\n- Fix A\n### Added\n- Add B")
+	notes.Merge(ParseNotes("### Fixed\n- Fix A\n- Fix C\n### Breaking Changes\n- Remove D"))
+	want := "### Breaking Changes\n\n- Remove D\n\n### Added\n\n- Add B\n\n### Fixed\n\n- Fix A\n- Fix C"
+	if got := notes.Markdown(); got != want {
+		t.Fatalf("Markdown() =\n%s\nwant:\n%s", got, want)
+	}
+	if (Notes{}).Empty() != true || notes.Empty() {
+		t.Fatal("unexpected Empty() result")
+	}
+}
+
+func TestUpdateCreatesChangelog(t *testing.T) {
+	got := Update("", "v1.0.0", "2024-05-01", ParseNotes("### Added\n- First release"))
+	if !strings.HasPrefix(got, "# Changelog\n") {
+		t.Fatalf("expected standard header, got:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "## [v1.0.0] - 2024-05-01\n\n### Added\n\n- First release\n") {
+		t.Fatalf("unexpected changelog:\n%s", got)
+	}
+}
+
+func TestUpdateInsertsAboveNewestRelease(t *testing.T) {
+	existing := "# Changelog\n\nIntro.\n\n## [v1.0.0] - 2024-05-01\n\n### Added\n\n- First release\n\n[v1.0.0]: https://example.com/v1.0.0\n"
+	got := Update(existing, "v1.1.0", "2024-06-01", ParseNotes("### Fixed\n- Fix bug"))
+	want := "# Changelog\n\nIntro.\n\n## [v1.1.0] - 2024-06-01\n\n### Fixed\n\n- Fix bug\n\n## [v1.0.0] - 2024-05-01\n\n### Added\n\n- First release\n\n[v1.0.0]: https://example.com/v1.0.0\n"
+	if got != want {
+		t.Fatalf("Update() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUpdateReplacesExistingSection(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Old entry\n\n## [v1.0.0] - 2024-05-01\n\n- First release\n"
+	got := Update(existing, "", "", ParseNotes("### Changed\n- New entry"))
+	want := "# Changelog\n\n## [Unreleased]\n\n### Changed\n\n- New entry\n\n## [v1.0.0] - 2024-05-01\n\n- First release\n"
+	if got != want {
+		t.Fatalf("Update() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUpdateFoldsUnreleasedIntoRelease(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Hand-written entry\n\n## [v1.0.0] - 2024-05-01\n\n- First release\n\n## [v0.9.0] - 2024-04-01\n\n- Beta\n"
+	got := Update(existing, "v1.1.0", "2024-06-01", ParseNotes("### Added\n- Hand-written entry\n### Fixed\n- Fix bug"))
+	want := "# Changelog\n\n## [Unreleased]\n\n## [v1.1.0] - 2024-06-01\n\n### Added\n\n- Hand-written entry\n\n### Fixed\n\n- Fix bug\n\n## [v1.0.0] - 2024-05-01\n\n- First release\n\n## [v0.9.0] - 2024-04-01\n\n- Beta\n"
+	if got != want {
+		t.Fatalf("Update() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// With only an Unreleased section the release still goes below it.
+	existing = "# Changelog\n\n## [Unreleased]\n\n### Changed\n\n- Tweak\n"
+	got = Update(existing, "v1.0.0", "", ParseNotes("### Added\n- First release"))
+	want = "# Changelog\n\n## [Unreleased]\n\n## [v1.0.0]\n\n### Added\n\n- First release\n\n### Changed\n\n- Tweak\n"
+	if got != want {
+		t.Fatalf("Update() =\n%s\nwant:\n%s", got, want)
+	}
+}
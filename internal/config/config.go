@@ -18,6 +18,7 @@ const (
 	DefaultMaxTokens           = 400
 	DefaultTemperature         = 0.7
 	DefaultTimeoutSeconds      = 30
+	DefaultStyleExamples       = 5
 	MaxModelNameLength         = 255
 )
 
@@ -29,15 +30,94 @@ var (
 	}
 
 	ModelNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+/[a-zA-Z0-9._:-]+$`)
+
+	// DiffAlgorithms are the values git accepts for --diff-algorithm.
+	DiffAlgorithms = []string{"myers", "minimal", "patience", "histogram"}
 )
 
 type Config struct {
-	OpenRouterAPIKey string   `json:"open_router_api_key"`
-	Models           []string `json:"models,omitempty"`
-	MaxTokens        int      `json:"max_tokens,omitempty"`
-	Temperature      float64  `json:"temperature,omitempty"`
-	APIURL           string   `json:"api_url,omitempty"`
-	TimeoutSeconds   int      `json:"timeout_seconds,omitempty"`
+	OpenRouterAPIKey string        `json:"open_router_api_key,omitempty"`
+	APIKeyCommand    string        `json:"api_key_command,omitempty"`
+	APIKeys          []APIKeyEntry `json:"api_keys,omitempty"`
+	Models           []string      `json:"models,omitempty"`
+	MaxTokens        int           `json:"max_tokens,omitempty"`
+	Temperature      float64       `json:"temperature,omitempty"`
+	APIURL           string        `json:"api_url,omitempty"`
+	TimeoutSeconds   int           `json:"timeout_seconds,omitempty"`
+	// IgnorePatterns are gitignore-style patterns applied in every repository
+	// before the repo-level .gitcommignore.
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+	// PRBaseBranch is the branch `gitcomm pr` compares against when -base is
+	// not given. Empty means origin's default branch, then main or master.
+	PRBaseBranch string `json:"pr_base_branch,omitempty"`
+	// StyleExamples is how many recent commit messages are quoted in the
+	// prompt as examples of the repository's style; 0 still learns the
+	// style but quotes none. Not omitempty, so an explicit 0 is kept.
+	StyleExamples int `json:"style_examples"`
+	// DisableStyleLearning stops gitcomm from reading the repository's
+	// history to match its commit style.
+	DisableStyleLearning bool `json:"disable_style_learning,omitempty"`
+	// TicketPatterns are regular expressions that find ticket IDs in the
+	// branch name; the first capture group, or the whole match, is the ID.
+	TicketPatterns []string `json:"ticket_patterns,omitempty"`
+	// TicketTemplate places the IDs: "{ticket}: {subject}" rewrites the
+	// subject, anything else ("Refs: {ticket}", "Closes #{ticket}") is
+	// added as a footer line. Empty means "Refs: {ticket}".
+	TicketTemplate string `json:"ticket_template,omitempty"`
+	// Trailers are added to every generated message, e.g.
+	// "Signed-off-by: {name} <{email}>", where {name} and {email} are the
+	// committer's identity.
+	Trailers []string `json:"trailers,omitempty"`
+	// CoAuthors are the people you are pairing with, as "Name <email>";
+	// each gets a Co-authored-by trailer. Managed with `gitcomm pair`.
+	CoAuthors []string `json:"co_authors,omitempty"`
+	// DiffNoRenames turns off rename detection, which is on by default.
+	DiffNoRenames bool `json:"diff_no_renames,omitempty"`
+	// DiffFindCopies also detects copied files.
+	DiffFindCopies bool `json:"diff_find_copies,omitempty"`
+	// DiffIgnoreWhitespace and DiffIgnoreBlankLines leave whitespace-only
+	// changes out of the diff sent to the model.
+	DiffIgnoreWhitespace bool `json:"diff_ignore_whitespace,omitempty"`
+	DiffIgnoreBlankLines bool `json:"diff_ignore_blank_lines,omitempty"`
+	// DiffContext is the number of context lines around each change; unset
+	// uses git's default (diff.context, normally 3).
+	DiffContext *int `json:"diff_context,omitempty"`
+	// DiffFunctionContext sends the whole function around each change.
+	DiffFunctionContext bool `json:"diff_function_context,omitempty"`
+	// DiffAlgorithm is one of DiffAlgorithms; empty uses git's default.
+	DiffAlgorithm string `json:"diff_algorithm,omitempty"`
+}
+
+// APIKeyEntry is one member of the API key pool. Exactly one of Key or Command
+// is expected; Command is run through the shell and its first output line is
+// used as the key.
+type APIKeyEntry struct {
+	Key     string `json:"key,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// KeyPool returns the ordered keys SendPrompt may rotate through: the primary
+// key (config or environment) first, then api_key_command, followed by the
+// api_keys entries.
+func (c *Config) KeyPool() []APIKeyEntry {
+	pool := make([]APIKeyEntry, 0, len(c.APIKeys)+1)
+	seen := make(map[APIKeyEntry]bool, len(c.APIKeys)+1)
+	add := func(entry APIKeyEntry) {
+		if entry.Key == "" && entry.Command == "" {
+			return
+		}
+		if seen[entry] {
+			return
+		}
+		seen[entry] = true
+		pool = append(pool, entry)
+	}
+	add(APIKeyEntry{Key: c.OpenRouterAPIKey})
+	add(APIKeyEntry{Command: c.APIKeyCommand})
+	for _, entry := range c.APIKeys {
+		add(entry)
+	}
+	return pool
 }
 
 func Dir() (string, error) {
@@ -66,6 +146,7 @@ func DefaultConfig() *Config {
 		Temperature:    DefaultTemperature,
 		APIURL:         OpenRouterAPIURL,
 		TimeoutSeconds: DefaultTimeoutSeconds,
+		StyleExamples:  DefaultStyleExamples,
 	}
 }
 
@@ -144,13 +225,18 @@ func SaveConfig(config *Config) error {
 }
 
 func normalizeModels(models []string) []string {
-	if len(models) == 0 {
+	return normalizeList(models)
+}
+
+// normalizeList trims each value and drops empty ones.
+func normalizeList(values []string) []string {
+	if len(values) == 0 {
 		return nil
 	}
 
-	normalized := make([]string, 0, len(models))
-	for _, model := range models {
-		trimmed := strings.TrimSpace(model)
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		trimmed := strings.TrimSpace(value)
 		if trimmed != "" {
 			normalized = append(normalized, trimmed)
 		}
@@ -159,6 +245,28 @@ func normalizeModels(models []string) []string {
 	return normalized
 }
 
+func normalizeAPIKeys(entries []APIKeyEntry) []APIKeyEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	normalized := make([]APIKeyEntry, 0, len(entries))
+	for i, entry := range entries {
+		entry.Key = strings.TrimSpace(entry.Key)
+		entry.Command = strings.TrimSpace(entry.Command)
+		if entry.Key == "" && entry.Command == "" {
+			diag.Warn("config", "ignoring empty api_keys entry", "index", i)
+			continue
+		}
+		if entry.Key != "" && entry.Command != "" {
+			diag.Warn("config", "api_keys entry has both key and command; using key", "index", i)
+			entry.Command = ""
+		}
+		normalized = append(normalized, entry)
+	}
+	return normalized
+}
+
 func normalizeRuntimeConfig(cfg *Config) {
 	cfg.Models = normalizeModels(cfg.Models)
 	validatedModels := make([]string, 0, len(cfg.Models))
@@ -176,6 +284,12 @@ func normalizeRuntimeConfig(cfg *Config) {
 		}
 	}
 	cfg.Models = validatedModels
+	cfg.APIKeyCommand = strings.TrimSpace(cfg.APIKeyCommand)
+	cfg.APIKeys = normalizeAPIKeys(cfg.APIKeys)
+	cfg.PRBaseBranch = strings.TrimSpace(cfg.PRBaseBranch)
+	cfg.TicketTemplate = strings.TrimSpace(cfg.TicketTemplate)
+	cfg.Trailers = normalizeList(cfg.Trailers)
+	cfg.CoAuthors = normalizeList(cfg.CoAuthors)
 
 	if cfg.MaxTokens < 0 {
 		diag.Warn("config", "negative max_tokens reset to zero", "value", cfg.MaxTokens)
@@ -189,6 +303,19 @@ func normalizeRuntimeConfig(cfg *Config) {
 		diag.Warn("config", "negative timeout reset to zero", "value", cfg.TimeoutSeconds)
 		cfg.TimeoutSeconds = 0
 	}
+	if cfg.DiffContext != nil && *cfg.DiffContext < 0 {
+		diag.Warn("config", "negative diff_context reset to git's default", "value", *cfg.DiffContext)
+		cfg.DiffContext = nil
+	}
+	cfg.DiffAlgorithm = strings.ToLower(strings.TrimSpace(cfg.DiffAlgorithm))
+	if cfg.DiffAlgorithm != "" && !ValidDiffAlgorithm(cfg.DiffAlgorithm) {
+		diag.Warn("config", "ignoring unknown diff_algorithm", "value", cfg.DiffAlgorithm)
+		cfg.DiffAlgorithm = ""
+	}
+	if cfg.StyleExamples < 0 {
+		diag.Warn("config", "negative style_examples reset to zero", "value", cfg.StyleExamples)
+		cfg.StyleExamples = 0
+	}
 }
 
 func applyEnvOverrides(cfg *Config) {
@@ -214,3 +341,13 @@ func ValidateModelName(model string) error {
 	}
 	return nil
 }
+
+// ValidDiffAlgorithm reports whether name is one of DiffAlgorithms.
+func ValidDiffAlgorithm(name string) bool {
+	for _, algorithm := range DiffAlgorithms {
+		if name == algorithm {
+			return true
+		}
+	}
+	return false
+}
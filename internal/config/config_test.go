@@ -71,3 +71,117 @@ func TestLoadConfigFallsBackWhenModelsInvalid(t *testing.T) {
 		t.Fatalf("expected default models, got %v", cfg.Models)
 	}
 }
+
+func TestLoadConfigStyleExamplesDefaultAndExplicitZero(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if cfg, err := LoadConfig(); err != nil || cfg.StyleExamples != DefaultStyleExamples || cfg.DisableStyleLearning {
+		t.Fatalf("LoadConfig() = %+v, %v; want default style settings", cfg, err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.StyleExamples = 0
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.StyleExamples != 0 {
+		t.Fatalf("explicit style_examples 0 should survive a save, got %d", loaded.StyleExamples)
+	}
+}
+
+func TestLoadConfigNormalizesDiffOptions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".gitcomm"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".gitcomm", "config.json")
+	write := func(content string) *Config {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig() error = %v", err)
+		}
+		return cfg
+	}
+
+	cfg := write(`{"diff_algorithm":" Histogram ","diff_context":8,"diff_ignore_whitespace":true}`)
+	if cfg.DiffAlgorithm != "histogram" || cfg.DiffContext == nil || *cfg.DiffContext != 8 || !cfg.DiffIgnoreWhitespace {
+		t.Fatalf("unexpected diff settings %+v", cfg)
+	}
+	cfg = write(`{"diff_algorithm":"fastest","diff_context":-2}`)
+	if cfg.DiffAlgorithm != "" || cfg.DiffContext != nil {
+		t.Fatalf("invalid diff settings should fall back to git's defaults, got %q and %v", cfg.DiffAlgorithm, cfg.DiffContext != nil)
+	}
+	if cfg = write(`{"diff_context":0}`); cfg.DiffContext == nil || *cfg.DiffContext != 0 {
+		t.Fatalf("an explicit zero diff_context should be kept, got %v", cfg.DiffContext)
+	}
+}
+
+func TestKeyPoolOrdersPrimaryKeyFirstAndSkipsDuplicates(t *testing.T) {
+	cfg := &Config{
+		OpenRouterAPIKey: "primary",
+		APIKeys: []APIKeyEntry{
+			{Key: "second"},
+			{Key: "primary"},
+			{Command: "pass show openrouter"},
+		},
+	}
+	pool := cfg.KeyPool()
+	if len(pool) != 3 {
+		t.Fatalf("expected 3 keys, got %+v", pool)
+	}
+	if pool[0].Key != "primary" || pool[1].Key != "second" || pool[2].Command != "pass show openrouter" {
+		t.Fatalf("unexpected pool order: %+v", pool)
+	}
+}
+
+func TestResolveAPIKeyRunsCommand(t *testing.T) {
+	key, err := ResolveAPIKey(APIKeyEntry{Command: "printf 'from-command\\nignored\\n'"})
+	if err != nil {
+		t.Fatalf("ResolveAPIKey() error = %v", err)
+	}
+	if key != "from-command" {
+		t.Fatalf("expected first output line, got %q", key)
+	}
+
+	if _, err := ResolveAPIKey(APIKeyEntry{Command: "exit 3"}); err == nil {
+		t.Fatal("expected failing command to return an error")
+	}
+}
+
+func TestKeyPoolIncludesAPIKeyCommandAfterPrimaryKey(t *testing.T) {
+	cfg := &Config{OpenRouterAPIKey: "env-key", APIKeyCommand: "pass show openrouter"}
+	pool := cfg.KeyPool()
+	if len(pool) != 2 || pool[0].Key != "env-key" || pool[1].Command != "pass show openrouter" {
+		t.Fatalf("unexpected pool: %+v", pool)
+	}
+}
+
+func TestResolveAPIKeyCachesCommandOutputForProcess(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	command := "echo run >> " + counter + "; echo cached-key"
+	for i := 0; i < 2; i++ {
+		key, err := ResolveAPIKey(APIKeyEntry{Command: command})
+		if err != nil {
+			t.Fatalf("ResolveAPIKey() error = %v", err)
+		}
+		if key != "cached-key" {
+			t.Fatalf("unexpected key %q", key)
+		}
+	}
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Fatalf("expected command to run once, ran %d times", runs)
+	}
+}
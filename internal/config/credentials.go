@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+var (
+	execCommand = exec.Command
+
+	// keyCache holds command output for the lifetime of the process only, so a
+	// helper such as `pass show` runs at most once per invocation.
+	keyCacheMu sync.Mutex
+	keyCache   = map[string]string{}
+)
+
+// ResolveAPIKey returns the key for a pool entry, running its command when the
+// entry does not hold a literal key. The key itself is never logged.
+func ResolveAPIKey(entry APIKeyEntry) (string, error) {
+	if entry.Key != "" {
+		return entry.Key, nil
+	}
+	if entry.Command == "" {
+		return "", fmt.Errorf("API key entry has neither a key nor a command")
+	}
+	return runKeyCommand(entry.Command)
+}
+
+func runKeyCommand(command string) (string, error) {
+	keyCacheMu.Lock()
+	defer keyCacheMu.Unlock()
+	if key, ok := keyCache[command]; ok {
+		diag.Debug("config", "using cached api key command output")
+		return key, nil
+	}
+
+	cmd := shellCommand(command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		diag.Error("config", "api key command failed", "error", err, "stderr", diag.Snippet(msg, 200))
+		if msg != "" {
+			return "", fmt.Errorf("API key command failed: %s", msg)
+		}
+		return "", fmt.Errorf("API key command failed: %w", err)
+	}
+
+	key := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	if key == "" {
+		diag.Error("config", "api key command produced no output")
+		return "", fmt.Errorf("API key command produced no output")
+	}
+	keyCache[command] = key
+	diag.Debug("config", "api key command succeeded", "key_chars", len(key))
+	return key, nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return execCommand("cmd", "/C", command)
+	}
+	return execCommand("sh", "-c", command)
+}
@@ -0,0 +1,151 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+// CurrentBranch returns the short name of the checked-out branch, or "HEAD"
+// when detached.
+func CurrentBranch() (string, error) {
+	out, err := runGit("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// DefaultBaseBranch guesses the branch pull requests target: the remote's
+// HEAD when origin has one, otherwise the first of main or master that
+// exists locally or on origin.
+func DefaultBaseBranch() (string, error) {
+	if out, err := runGitQuiet("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if ref := strings.TrimSpace(out); ref != "" {
+			return ref, nil
+		}
+	}
+	for _, candidate := range []string{"main", "master", "origin/main", "origin/master"} {
+		if _, err := ResolveCommit(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the base branch; pass one with -base")
+}
+
+// MergeBase returns the best common ancestor of a and b.
+func MergeBase(a, b string) (string, error) {
+	out, err := runGitQuiet("merge-base", a, b)
+	if err != nil {
+		diag.Warn("git", "no merge base", "a", a, "b", b, "error", err)
+		return "", fmt.Errorf("%s and %s have no common history", a, b)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// PreviousTag returns the most recent tag reachable from rev, excluding a tag
+// that points at rev itself, or "" when there is none.
+func PreviousTag(rev string) string {
+	out, err := runGitQuiet("describe", "--tags", "--abbrev=0", rev+"^")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// TagAt returns a tag pointing exactly at rev, or "" when there is none.
+func TagAt(rev string) string {
+	out, err := runGitQuiet("describe", "--tags", "--exact-match", rev)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// CommitDate returns rev's committer date as YYYY-MM-DD.
+func CommitDate(rev string) (string, error) {
+	out, err := runGit("log", "-1", "--format=%cs", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ChangedPaths lists the files rev changes relative to its first parent (or
+// the empty tree for a root commit).
+func ChangedPaths(rev string) ([]string, error) {
+	out, err := runGit("diff-tree", "-r", "--root", "--no-commit-id", "--name-only", "-z", "-m", "--first-parent", rev)
+	if err != nil {
+		return nil, err
+	}
+	return splitNULPaths(out), nil
+}
+
+func splitNULPaths(output string) []string {
+	output = strings.TrimSuffix(output, "\x00")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\x00")
+}
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath.
+func HooksDir() (string, error) {
+	return current.HooksDir()
+}
+
+// HooksDir returns the directory git runs this repository's hooks from.
+func (r *Repo) HooksDir() (string, error) {
+	return r.GitPath("hooks")
+}
+
+// ConfigValue returns a git config value, or "" when it is unset.
+func ConfigValue(key string) string {
+	out, err := runGitQuiet("config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// ConfigValues returns every value of a multi-valued git config key.
+func ConfigValues(key string) []string {
+	out, err := runGitQuiet("config", "--get-all", key)
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+// CommitterIdent returns the name and email git records as the committer,
+// honouring user.name, user.email and the GIT_COMMITTER_* variables.
+func CommitterIdent() (name, email string, err error) {
+	out, err := runGit("var", "GIT_COMMITTER_IDENT")
+	if err != nil {
+		return "", "", err
+	}
+	ident := strings.TrimSpace(out)
+	open, end := strings.Index(ident, "<"), strings.Index(ident, ">")
+	if open < 0 || end < open {
+		return "", "", fmt.Errorf("unexpected committer identity %q", ident)
+	}
+	return strings.TrimSpace(ident[:open]), ident[open+1 : end], nil
+}
+
+// CommentChar returns the character git uses to mark comment lines in commit
+// messages.
+func CommentChar() string {
+	value := ConfigValue("core.commentChar")
+	if value == "" || value == "auto" {
+		return "#"
+	}
+	return value
+}
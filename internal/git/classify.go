@@ -0,0 +1,296 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+)
+
+const (
+	generatedHeaderLines = 10
+	// generatedHeaderBytes caps how much of each file is read to find a
+	// generated marker in its first generatedHeaderLines lines.
+	generatedHeaderBytes = 8 << 10
+	minifiedLineLength   = 500
+)
+
+var (
+	generatedHeaderExpr = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+	generatedMarkerExpr = regexp.MustCompile(`@generated\b|<auto-generated`)
+	minifiedPathExpr    = regexp.MustCompile(`\.min\.(js|css|mjs)$|\.(js|css)\.map$`)
+	minifiableExpr      = regexp.MustCompile(`\.(js|mjs|cjs|css|json|svg|map)$`)
+)
+
+type fileAttrs struct {
+	generated bool
+	vendored  bool
+	noDiff    bool
+}
+
+// collapseDetectedFiles replaces the hunks of generated, vendored, LFS
+// pointer, minified and binary files with a one-line description so the line
+// budget is spent on hand-written code. It returns the description for each
+// collapsed path.
+func (r *Repo) collapseDetectedFiles(patch *gitdiff.Patch, rev string) map[string]string {
+	paths := filePaths(patch)
+	attrs, err := r.checkAttrs(paths)
+	if err != nil {
+		diag.Warn("git", "failed to read gitattributes for staged files", "error", err)
+	}
+	heads, err := r.readBlobHeads(rev, paths, generatedHeaderLines)
+	if err != nil {
+		diag.Warn("git", "failed to read file headers", "rev", rev, "error", err)
+	}
+	return collapseFiles(patch, attrs, heads)
+}
+
+// collapseFiles collapses the files classifyFile picks out, given their
+// attributes and the first lines of their new content when known.
+func collapseFiles(patch *gitdiff.Patch, attrs map[string]fileAttrs, heads map[string]string) map[string]string {
+	collapsed := make(map[string]string)
+	for _, file := range patch.Files {
+		reason := classifyFile(file, attrs[file.Path()], heads[file.Path()])
+		if reason == "" {
+			continue
+		}
+		description := describeCollapsed(file, reason)
+		file.Collapse(description)
+		collapsed[file.Path()] = reason
+	}
+	if len(collapsed) > 0 {
+		diag.Info("git", "collapsed detected files", "count", len(collapsed))
+	}
+	return collapsed
+}
+
+func filePaths(patch *gitdiff.Patch) []string {
+	paths := make([]string, 0, len(patch.Files))
+	for _, file := range patch.Files {
+		paths = append(paths, file.Path())
+	}
+	return paths
+}
+
+// classifyFile returns a short reason when the file should not be sent to the
+// model line by line, or "" for ordinary hand-written content.
+func classifyFile(file *gitdiff.File, attrs fileAttrs, head string) string {
+	switch {
+	case attrs.generated:
+		return "generated file (linguist-generated)"
+	case attrs.vendored:
+		return "vendored file (linguist-vendored)"
+	case isLFSPointer(file):
+		return "Git LFS pointer"
+	case file.Binary:
+		return "binary file"
+	case attrs.noDiff:
+		return "diff disabled by .gitattributes (-diff)"
+	case hasGeneratedHeader(file, head):
+		return "generated file (Code generated header)"
+	case isMinified(file):
+		return "minified asset"
+	}
+	return ""
+}
+
+func describeCollapsed(file *gitdiff.File, reason string) string {
+	if file.Binary && len(file.Hunks) == 0 {
+		return reason + "; content omitted"
+	}
+	return fmt.Sprintf("%s; %d lines added, %d removed; hunks omitted", reason, file.Added, file.Removed)
+}
+
+// hasGeneratedHeader reports whether a generated marker appears in the first
+// generatedHeaderLines lines of the old or new content, looking at the hunks
+// that reach them and at head.
+func hasGeneratedHeader(file *gitdiff.File, head string) bool {
+	for _, hunk := range file.Hunks {
+		oldLine, newLine := hunk.OldStart, hunk.NewStart
+		for _, line := range hunk.Lines {
+			if oldLine > generatedHeaderLines && newLine > generatedHeaderLines {
+				break
+			}
+			inHeader := false
+			switch line.Kind {
+			case gitdiff.LineAdded:
+				inHeader = newLine <= generatedHeaderLines
+				newLine++
+			case gitdiff.LineRemoved:
+				inHeader = oldLine <= generatedHeaderLines
+				oldLine++
+			default:
+				inHeader = true
+				oldLine++
+				newLine++
+			}
+			if inHeader && isGeneratedMarker(line.Text) {
+				return true
+			}
+		}
+	}
+	for _, line := range strings.Split(head, "\n") {
+		if isGeneratedMarker(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGeneratedMarker(line string) bool {
+	line = strings.TrimSpace(line)
+	return generatedHeaderExpr.MatchString(line) || generatedMarkerExpr.MatchString(line)
+}
+
+func isLFSPointer(file *gitdiff.File) bool {
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind != gitdiff.LineContext && strings.HasPrefix(line.Text, "version https://git-lfs.github.com/spec/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isMinified(file *gitdiff.File) bool {
+	path := file.Path()
+	if minifiedPathExpr.MatchString(path) {
+		return true
+	}
+	if !minifiableExpr.MatchString(path) {
+		return false
+	}
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == gitdiff.LineAdded && len(line.Text) > minifiedLineLength {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *Repo) checkAttrs(paths []string) (map[string]fileAttrs, error) {
+	attrs := make(map[string]fileAttrs, len(paths))
+	if len(paths) == 0 {
+		return attrs, nil
+	}
+	cmd := r.command("check-attr", "-z", "--stdin", "linguist-generated", "linguist-vendored", "diff")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return attrs, fmt.Errorf("git check-attr: %s", firstErrorLine(stderr.String()))
+	}
+
+	fields := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		path, name, value := fields[i], fields[i+1], fields[i+2]
+		entry := attrs[path]
+		switch name {
+		case "linguist-generated":
+			entry.generated = isAttrSet(value)
+		case "linguist-vendored":
+			entry.vendored = isAttrSet(value)
+		case "diff":
+			entry.noDiff = value == "unset"
+		}
+		attrs[path] = entry
+	}
+	return attrs, nil
+}
+
+func isAttrSet(value string) bool {
+	return value == "set" || value == "true"
+}
+
+// readBlobHeads returns the first maxLines lines of each path as stored in
+// rev (or the index when rev is empty), reading at most generatedHeaderBytes
+// of each file.
+func (r *Repo) readBlobHeads(rev string, paths []string, maxLines int) (map[string]string, error) {
+	blobs, err := r.readBlobs(rev, paths, generatedHeaderBytes)
+	heads := make(map[string]string, len(blobs))
+	for path, content := range blobs {
+		lines := strings.SplitN(string(content), "\n", maxLines+1)
+		if len(lines) > maxLines {
+			lines = lines[:maxLines]
+		}
+		heads[path] = strings.Join(lines, "\n")
+	}
+	return heads, err
+}
+
+// readBlobs returns up to limit bytes of each path as stored in rev (or the
+// index when rev is empty) using a single `git cat-file --batch` process. The
+// output is streamed, so larger blobs are cut at limit rather than held in
+// memory. Paths that do not exist there are left out.
+func (r *Repo) readBlobs(rev string, paths []string, limit int) (map[string][]byte, error) {
+	blobs := make(map[string][]byte, len(paths))
+	if len(paths) == 0 {
+		return blobs, nil
+	}
+	var input strings.Builder
+	for _, path := range paths {
+		input.WriteString(rev + ":" + path + "\n")
+	}
+	cmd := r.command("cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(input.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return blobs, err
+	}
+	if err := cmd.Start(); err != nil {
+		return blobs, err
+	}
+	readErr := readBatch(bufio.NewReader(stdout), paths, limit, blobs)
+	if readErr != nil {
+		// Drain the rest so git is not blocked writing to a full pipe.
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return blobs, fmt.Errorf("git cat-file: %s", firstErrorLine(stderr.String()))
+	}
+	return blobs, readErr
+}
+
+// readBatch parses `git cat-file --batch` output for paths, keeping at most
+// limit bytes of each blob and discarding the rest.
+func readBatch(reader *bufio.Reader, paths []string, limit int, blobs map[string][]byte) error {
+	for _, path := range paths {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			// "<object> missing" for deleted paths or paths with newlines.
+			continue
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("unexpected cat-file header %q", strings.TrimSpace(header))
+		}
+		content := make([]byte, min(size, limit))
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return err
+		}
+		// Skip the rest of the object and its trailing newline.
+		if _, err := reader.Discard(size - len(content) + 1); err != nil {
+			return err
+		}
+		if fields[1] == "blob" {
+			blobs[path] = content
+		}
+	}
+	return nil
+}
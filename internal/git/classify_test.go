@@ -0,0 +1,165 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+)
+
+func parseSingleFile(t *testing.T, lines ...string) *gitdiff.File {
+	t.Helper()
+	patch := gitdiff.Parse(strings.Join(lines, "\n") + "\n")
+	if len(patch.Files) != 1 {
+		t.Fatalf("expected one file, got %d", len(patch.Files))
+	}
+	return patch.Files[0]
+}
+
+func TestClassifyFileDetectsGeneratedHeaderInDiff(t *testing.T) {
+	file := parseSingleFile(t,
+		"diff --git a/api.pb.go b/api.pb.go",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/api.pb.go",
+		"@@ -0,0 +1,3 @@",
+		"+// Code generated by protoc-gen-go. DO NOT EDIT.",
+		"+",
+		"+package api",
+	)
+	if got := classifyFile(file, fileAttrs{}, ""); got != "generated file (Code generated header)" {
+		t.Fatalf("unexpected classification %q", got)
+	}
+}
+
+func TestClassifyFileDetectsGeneratedHeaderFromIndexContent(t *testing.T) {
+	file := parseSingleFile(t,
+		"diff --git a/zz_deepcopy.go b/zz_deepcopy.go",
+		"--- a/zz_deepcopy.go",
+		"+++ b/zz_deepcopy.go",
+		"@@ -120,1 +120,1 @@",
+		"-	old()",
+		"+	new()",
+	)
+	if got := classifyFile(file, fileAttrs{}, ""); got != "" {
+		t.Fatalf("did not expect classification without header, got %q", got)
+	}
+	head := "//go:build !ignore_autogenerated\n\n// Code generated by controller-gen. DO NOT EDIT.\n"
+	if got := classifyFile(file, fileAttrs{}, head); got == "" {
+		t.Fatal("expected header from index content to mark file generated")
+	}
+}
+
+func TestClassifyFileUsesAttributes(t *testing.T) {
+	file := parseSingleFile(t,
+		"diff --git a/third_party/lib.c b/third_party/lib.c",
+		"--- a/third_party/lib.c",
+		"+++ b/third_party/lib.c",
+		"@@ -1 +1 @@",
+		"-a",
+		"+b",
+	)
+	cases := map[string]fileAttrs{
+		"generated file (linguist-generated)":     {generated: true},
+		"vendored file (linguist-vendored)":       {vendored: true},
+		"diff disabled by .gitattributes (-diff)": {noDiff: true},
+	}
+	for want, attrs := range cases {
+		if got := classifyFile(file, attrs, ""); got != want {
+			t.Fatalf("classifyFile(%+v) = %q, want %q", attrs, got, want)
+		}
+	}
+}
+
+func TestClassifyFileDetectsLFSPointerAndMinifiedAssets(t *testing.T) {
+	lfs := parseSingleFile(t,
+		"diff --git a/video.mp4 b/video.mp4",
+		"--- a/video.mp4",
+		"+++ b/video.mp4",
+		"@@ -1,3 +1,3 @@",
+		" version https://git-lfs.github.com/spec/v1",
+		"-oid sha256:aaaa",
+		"-size 10",
+		"+oid sha256:bbbb",
+		"+size 20",
+	)
+	if got := classifyFile(lfs, fileAttrs{}, ""); got != "" {
+		t.Fatalf("context-only LFS version line should not match, got %q", got)
+	}
+	lfs.Hunks[0].Lines[0].Kind = gitdiff.LineAdded
+	if got := classifyFile(lfs, fileAttrs{}, ""); got != "Git LFS pointer" {
+		t.Fatalf("unexpected classification %q", got)
+	}
+
+	minified := parseSingleFile(t,
+		"diff --git a/static/app.js b/static/app.js",
+		"--- a/static/app.js",
+		"+++ b/static/app.js",
+		"@@ -1 +1 @@",
+		"-var a=1;",
+		"+"+strings.Repeat("var a=1;", 100),
+	)
+	if got := classifyFile(minified, fileAttrs{}, ""); got != "minified asset" {
+		t.Fatalf("unexpected classification %q", got)
+	}
+	byName := parseSingleFile(t, "diff --git a/dist/app.min.css b/dist/app.min.css", "--- a/dist/app.min.css", "+++ b/dist/app.min.css", "@@ -1 +1 @@", "-a{}", "+b{}")
+	if got := classifyFile(byName, fileAttrs{}, ""); got != "minified asset" {
+		t.Fatalf("unexpected classification %q", got)
+	}
+}
+
+func TestCollapseKeepsOneLineDescription(t *testing.T) {
+	file := parseSingleFile(t,
+		"diff --git a/api.pb.go b/api.pb.go",
+		"--- a/api.pb.go",
+		"+++ b/api.pb.go",
+		"@@ -1,2 +1,2 @@",
+		" // Code generated by protoc-gen-go. DO NOT EDIT.",
+		"-old",
+		"+new",
+	)
+	file.Collapse(describeCollapsed(file, "generated file (Code generated header)"))
+	got := strings.Join(file.Lines(), "\n")
+	want := "diff --git a/api.pb.go b/api.pb.go\n[[gitcomm: generated file (Code generated header); 1 lines added, 1 removed; hunks omitted]]"
+	if got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestGeneratedMarkerOnlyCountsInFirstLines(t *testing.T) {
+	lines := []string{
+		"diff --git a/docs.go b/docs.go",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/docs.go",
+		"@@ -0,0 +1,12 @@",
+	}
+	for i := 0; i < 11; i++ {
+		lines = append(lines, "+// line")
+	}
+	lines = append(lines, "+// Tag exported helpers with @generated when a tool writes them.")
+	if got := classifyFile(parseSingleFile(t, lines...), fileAttrs{}, ""); got != "" {
+		t.Fatalf("did not expect a marker past the header to count, got %q", got)
+	}
+}
+
+func TestReadBlobsCutsEachBlobAtLimit(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "big.txt", strings.Repeat("x", 100000))
+	writeFile(t, "small.txt", "small\n")
+	gitRun(t, "add", ".")
+
+	blobs, err := Default().readBlobs("", []string{"big.txt", "missing.txt", "small.txt"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(blobs["big.txt"]); got != strings.Repeat("x", 10) {
+		t.Fatalf("big.txt = %q, want its first 10 bytes", got)
+	}
+	if got := string(blobs["small.txt"]); got != "small\n" {
+		t.Fatalf("small.txt = %q", got)
+	}
+	if _, ok := blobs["missing.txt"]; ok {
+		t.Fatal("did not expect a missing path to be returned")
+	}
+}
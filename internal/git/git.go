@@ -1,17 +1,26 @@
 package git
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/ignore"
+	"github.com/ktappdev/gitcomm/internal/symbols"
 )
 
 const MaxDiffLines = 1500
 
-func StageAll() error {
-	cmd := exec.Command("git", "add", ".")
+// StageAll stages every change under the repository's directory.
+func (r *Repo) StageAll() error {
+	cmd := r.command("add", ".")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		msg := strings.TrimSpace(string(output))
@@ -24,44 +33,324 @@ func StageAll() error {
 	return nil
 }
 
-func GetStagedChanges() (string, error) {
-	cmd := exec.Command("git", "diff", "--cached")
+// DiffOptions controls how the staged diff is collected and filtered.
+type DiffOptions struct {
+	// Exclude drops matching paths from the patch; they stay in Files.
+	Exclude *ignore.Matcher
+	// Base compares the index against this revision instead of HEAD, e.g.
+	// HEAD^ when regenerating the message for an amended commit.
+	Base string
+	// Head, when set, diffs Base against this commit instead of the index.
+	Head string
+
+	// NoRenames turns off rename detection (-M), which is on by default.
+	NoRenames bool
+	// FindCopies also detects copied files (-C).
+	FindCopies bool
+	// IgnoreWhitespace (-w) and IgnoreBlankLines leave whitespace-only
+	// changes out of the patch.
+	IgnoreWhitespace bool
+	IgnoreBlankLines bool
+	// Context is the number of context lines (-U); nil uses git's default.
+	Context *int
+	// FunctionContext shows the whole function around each change.
+	FunctionContext bool
+	// Algorithm is the diff algorithm, e.g. patience or histogram; "" uses
+	// git's default.
+	Algorithm string
+
+	// Progress receives the one-line account of the analyzed diff; nil
+	// means os.Stdout.
+	Progress io.Writer
+}
+
+func (o DiffOptions) diffArgs(extra ...string) []string {
+	args := []string{"diff"}
+	if o.Head == "" {
+		args = append(args, "--cached")
+	}
+	if o.NoRenames {
+		args = append(args, "--no-renames")
+	} else {
+		args = append(args, "-M")
+	}
+	if o.FindCopies {
+		args = append(args, "-C")
+	}
+	args = append(args, extra...)
+	if o.Base != "" {
+		args = append(args, o.Base)
+	}
+	if o.Head != "" {
+		args = append(args, o.Head)
+	}
+	return append(args, "--")
+}
+
+// patchArgs are the options that only shape the patch text, not which files
+// changed.
+func (o DiffOptions) patchArgs() []string {
+	args := o.whitespaceArgs()
+	if o.Context != nil {
+		args = append(args, fmt.Sprintf("-U%d", *o.Context))
+	}
+	if o.FunctionContext {
+		args = append(args, "--function-context")
+	}
+	if o.Algorithm != "" {
+		args = append(args, "--diff-algorithm="+o.Algorithm)
+	}
+	return args
+}
+
+// whitespaceArgs are the options that leave whitespace changes out. The
+// line counts need them too, so they match the patch.
+func (o DiffOptions) whitespaceArgs() []string {
+	var args []string
+	if o.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	if o.IgnoreBlankLines {
+		args = append(args, "--ignore-blank-lines")
+	}
+	return args
+}
+
+// blobRev is the revision prefix used to read file contents for the diff's
+// new side: "" for the index, or the Head commit.
+func (o DiffOptions) blobRev() string {
+	return o.Head
+}
+
+// StagedChanges is the staged diff prepared for analysis together with a
+// summary of every changed file.
+type StagedChanges struct {
+	// Diff is the patch text, truncated to MaxDiffLines when necessary.
+	Diff       string
+	Files      []gitdiff.FileStat
+	TotalLines int
+	Truncated  bool
+	// ExcludedFiles and ExcludedLines count what ignore patterns removed.
+	ExcludedFiles int
+	ExcludedLines int
+	// CollapsedFiles counts generated, vendored, LFS, minified and binary
+	// files reduced to a one-line description.
+	CollapsedFiles int
+	// Submodules describes gitlink changes, which the patch only shows as
+	// "Subproject commit" lines.
+	Submodules []gitdiff.Submodule
+	// Symbols lists the functions, methods and types added, removed or
+	// modified in the changed source files.
+	Symbols []symbols.Change
+}
+
+// GetCommitChanges collects the change introduced by a single commit,
+// comparing it with its first parent or the empty tree for a root commit.
+func (r *Repo) GetCommitChanges(rev string, opts DiffOptions) (*StagedChanges, error) {
+	base := rev + "^"
+	if _, err := r.runQuiet("rev-parse", "--verify", "--quiet", base); err != nil {
+		if base, err = r.emptyTree(); err != nil {
+			return nil, err
+		}
+	}
+	opts.Base = base
+	opts.Head = rev
+	return r.GetStagedChanges(opts)
+}
+
+// GetStagedChanges collects the staged diff, filtered, collapsed and
+// truncated for the prompt, together with a summary of every changed file.
+func (r *Repo) GetStagedChanges(opts DiffOptions) (*StagedChanges, error) {
+	args := opts.diffArgs(opts.patchArgs()...)
+	diag.Info("git", "collecting diff", "renames", !opts.NoRenames, "copies", opts.FindCopies, "ignore_whitespace", opts.IgnoreWhitespace, "ignore_blank_lines", opts.IgnoreBlankLines, "function_context", opts.FunctionContext, "algorithm", opts.Algorithm, "args", strings.Join(args, " "))
+	cmd := r.command(args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		msg := strings.TrimSpace(string(output))
+		msg := firstErrorLine(string(output))
 		if msg != "" {
-			lines := strings.Split(msg, "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if line == "" {
-					continue
-				}
-				if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
-					msg = line
-					break
-				}
-			}
-			diag.Error("git", "git diff --cached failed", "error", err, "output", diag.Snippet(msg, 300))
-			return "", fmt.Errorf("%s", msg)
+			diag.Error("git", "git diff --cached failed", "args", strings.Join(args, " "), "error", err, "output", diag.Snippet(msg, 300))
+			return nil, fmt.Errorf("%s", msg)
 		}
-		return "", err
+		return nil, err
+	}
+
+	fullPatch := gitdiff.Parse(string(output))
+	changes, excluded, collapsed := prepareChanges(fullPatch, opts.Exclude, opts.Progress, func(patch *gitdiff.Patch) map[string]string {
+		return r.collapseDetectedFiles(patch, opts.blobRev())
+	})
+	changes.Submodules = r.describeSubmodules(fullPatch, opts.Exclude)
+	if len(fullPatch.Files) == 0 && strings.TrimSpace(changes.Diff) == "" {
+		diag.Warn("git", "staged diff is empty", "bytes", len(output), "lines", changes.TotalLines)
+		return changes, nil
 	}
 
-	res, wasTruncated := limitDiffSizeWithInfo(string(output), MaxDiffLines)
-	originalLines := countLines(string(output))
-	returnedLines := countLines(res)
-	fmt.Printf("📄 Analyzed %d lines of diff", minInt(originalLines, MaxDiffLines))
+	files, err := r.stagedFileStats(opts)
+	if err != nil {
+		diag.Warn("git", "failed to collect staged file summary", "error", err)
+	}
+	changes.Files = markOmitted(files, excluded, collapsed)
+	if len(opts.whitespaceArgs()) > 0 {
+		markWhitespaceOnly(changes.Files, fullPatch)
+	}
+	changes.Symbols = r.changedSymbols(changes.Files, opts)
+	diag.Info("git", "collected staged diff", "bytes", len(output), "lines", changes.TotalLines, "files", len(fullPatch.Files)-len(excluded), "summary_files", len(files), "returned_lines", countLines(changes.Diff), "truncated", changes.Truncated, "excluded_files", len(excluded), "excluded_lines", changes.ExcludedLines, "collapsed_files", len(collapsed))
+	return changes, nil
+}
+
+// ChangesFromPatch prepares a diff produced elsewhere, such as `git diff A B`
+// output or a format-patch file, the way GetStagedChanges prepares the
+// staged one. Everything is taken from the patch text, so it also works
+// outside a repository; inside one, .gitattributes still marks generated
+// and vendored files. Without the files' contents Symbols stays empty. Only
+// opts.Exclude and opts.Progress apply, since the patch is already made.
+func (r *Repo) ChangesFromPatch(text string, opts DiffOptions) *StagedChanges {
+	exclude := opts.Exclude
+	fullPatch := gitdiff.Parse(text)
+	files := fullPatch.FileStats()
+	changes, excluded, collapsed := prepareChanges(fullPatch, exclude, opts.Progress, func(patch *gitdiff.Patch) map[string]string {
+		attrs, err := r.checkAttrs(filePaths(patch))
+		if err != nil {
+			diag.Debug("git", "no gitattributes for patch files", "error", err)
+		}
+		return collapseFiles(patch, attrs, nil)
+	})
+	for _, sub := range fullPatch.Submodules() {
+		if !exclude.Match(sub.Path) {
+			changes.Submodules = append(changes.Submodules, sub)
+		}
+	}
+	changes.Files = markOmitted(files, excluded, collapsed)
+	diag.Info("git", "prepared patch", "bytes", len(text), "lines", changes.TotalLines, "files", len(fullPatch.Files), "returned_lines", countLines(changes.Diff), "truncated", changes.Truncated, "excluded_files", len(excluded), "collapsed_files", len(collapsed))
+	return changes
+}
+
+// prepareChanges filters fullPatch through exclude, collapses what collapse
+// picks out and truncates the rest to MaxDiffLines, printing a one-line
+// account of it to progress, or os.Stdout when it is nil. It returns the
+// changes without Files or Submodules, and the excluded files and collapse
+// reasons for markOmitted.
+func prepareChanges(fullPatch *gitdiff.Patch, exclude *ignore.Matcher, progress io.Writer, collapse func(*gitdiff.Patch) map[string]string) (*StagedChanges, []*gitdiff.File, map[string]string) {
+	patch, excluded := fullPatch.Filter(func(file *gitdiff.File) bool {
+		return !exclude.Match(file.Path())
+	})
+	excludedLines := fullPatch.LineCount() - patch.LineCount()
+	collapsed := collapse(patch)
+
+	res, wasTruncated := truncatePatch(patch, MaxDiffLines)
+	originalLines := patch.LineCount()
+	if progress == nil {
+		progress = os.Stdout
+	}
+	fmt.Fprintf(progress, "📄 Analyzed %d lines of diff", minInt(originalLines, MaxDiffLines))
 	if wasTruncated {
-		fmt.Printf(" (truncated from %d lines)", originalLines)
+		fmt.Fprintf(progress, " (truncated from %d lines)", originalLines)
+	}
+	if len(excluded) > 0 {
+		fmt.Fprintf(progress, " (excluded %d lines from %d %s via ignore patterns)", excludedLines, len(excluded), Pluralize(len(excluded), "file", "files"))
+	}
+	if len(collapsed) > 0 {
+		fmt.Fprintf(progress, " (collapsed %d generated, vendored or binary %s)", len(collapsed), Pluralize(len(collapsed), "file", "files"))
 	}
-	fmt.Println()
+	fmt.Fprintln(progress)
 
-	if strings.TrimSpace(res) == "" {
-		diag.Warn("git", "staged diff is empty", "bytes", len(output), "lines", originalLines)
-	} else {
-		diag.Info("git", "collected staged diff", "bytes", len(output), "lines", originalLines, "returned_lines", returnedLines, "truncated", wasTruncated)
+	changes := &StagedChanges{
+		Diff:           res,
+		TotalLines:     originalLines,
+		Truncated:      wasTruncated,
+		ExcludedFiles:  len(excluded),
+		ExcludedLines:  excludedLines,
+		CollapsedFiles: len(collapsed),
+	}
+	if strings.TrimSpace(res) == "" && len(excluded) > 0 {
+		// Keep a placeholder so callers do not mistake an all-excluded change
+		// for an empty one; the summary still lists every file.
+		changes.Diff = fmt.Sprintf("[[gitcomm: all %d changed files excluded by ignore patterns]]", len(excluded))
+	}
+	return changes, excluded, collapsed
+}
+
+// excludedReason is the Omitted text for files matched by ignore patterns.
+const excludedReason = "excluded by ignore patterns"
+
+// markOmitted records on files why their hunks are missing from the patch.
+func markOmitted(files []gitdiff.FileStat, excluded []*gitdiff.File, collapsed map[string]string) []gitdiff.FileStat {
+	excludedPaths := make(map[string]bool, len(excluded))
+	for _, file := range excluded {
+		excludedPaths[file.Path()] = true
+	}
+	for i := range files {
+		if excludedPaths[files[i].Path] {
+			files[i].Omitted = excludedReason
+		} else if reason, ok := collapsed[files[i].Path]; ok {
+			files[i].Omitted = reason
+		}
+	}
+	return files
+}
+
+// whitespaceOnlyReason is the Omitted text for files whose changes -w or
+// --ignore-blank-lines left out entirely.
+const whitespaceOnlyReason = "only whitespace changes"
+
+// markWhitespaceOnly marks the files missing from patch that numstat,
+// given the same whitespace options, counts as unchanged.
+func markWhitespaceOnly(files []gitdiff.FileStat, patch *gitdiff.Patch) {
+	inPatch := make(map[string]bool, len(patch.Files))
+	for _, file := range patch.Files {
+		inPatch[file.Path()] = true
+	}
+	for i := range files {
+		file := &files[i]
+		if file.Omitted == "" && !file.Binary && file.Added == 0 && file.Removed == 0 && !inPatch[file.Path] {
+			file.Omitted = whitespaceOnlyReason
+		}
+	}
+}
+
+// RepoRoot returns the top-level directory of the current work tree.
+func RepoRoot() (string, error) {
+	return current.Root()
+}
+
+func (r *Repo) stagedFileStats(opts DiffOptions) ([]gitdiff.FileStat, error) {
+	nameStatus, err := r.run(opts.diffArgs("--name-status", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+	files, err := gitdiff.ParseNameStatus(nameStatus)
+	if err != nil {
+		return nil, err
+	}
+	numstat, err := r.run(opts.diffArgs(append(opts.whitespaceArgs(), "--numstat", "-z")...)...)
+	if err != nil {
+		return files, err
+	}
+	if err := gitdiff.ApplyNumstat(files, numstat); err != nil {
+		return files, err
 	}
-	return res, nil
+	return files, nil
+}
+
+func runGit(args ...string) (string, error) {
+	return current.run(args...)
+}
+
+// runGitQuiet is runGit for probes whose failure is an expected answer, so
+// it does not log errors.
+func runGitQuiet(args ...string) (string, error) {
+	return current.runQuiet(args...)
+}
+
+// firstErrorLine prefers git's "fatal:" or "error:" line over the full output.
+func firstErrorLine(output string) string {
+	msg := strings.TrimSpace(output)
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+			return line
+		}
+	}
+	return msg
 }
 
 func limitDiffSizeWithInfo(diff string, maxLines int) (string, bool) {
@@ -69,12 +358,16 @@ func limitDiffSizeWithInfo(diff string, maxLines int) (string, bool) {
 		return diff, false
 	}
 
-	lines := splitDiffLines(diff)
-	if len(lines) <= maxLines {
-		return diff, false
+	return truncatePatch(gitdiff.Parse(diff), maxLines)
+}
+
+func truncatePatch(patch *gitdiff.Patch, maxLines int) (string, bool) {
+	kept, omitted := patch.Truncate(maxLines)
+	if omitted == 0 {
+		return patch.String(), false
 	}
 
-	truncated := strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (truncated, %d more lines)", len(lines)-maxLines)
+	truncated := strings.TrimSuffix(kept.String(), "\n") + fmt.Sprintf("\n... (truncated, %d more lines)", omitted)
 	return truncated, true
 }
 
@@ -83,14 +376,313 @@ func limitDiffSize(diff string, maxLines int) string {
 	return result
 }
 
-func Commit(message string) error {
-	cmd := exec.Command("git", "commit", "-m", message)
-	return cmd.Run()
+// CommitOptions are the `git commit` flags gitcomm can pass along.
+type CommitOptions struct {
+	// Amend replaces HEAD, folding in anything currently staged.
+	Amend bool
+	// Edit opens the message in git's editor before committing.
+	Edit     bool
+	Sign     bool
+	Signoff  bool
+	NoVerify bool
+	Author   string
+	// Quiet suppresses git's summary, for callers that report the commit
+	// themselves.
+	Quiet bool
+	// Extra holds further flags forwarded to git commit unchanged.
+	Extra []string
+}
+
+// commitMessageFile is written inside the git directory, next to git's own
+// COMMIT_EDITMSG.
+const commitMessageFile = "GITCOMM_MSG"
+
+func (o CommitOptions) args(messageFile string) []string {
+	args := []string{"commit", "-F", messageFile}
+	if o.Amend {
+		args = append(args, "--amend")
+	}
+	if o.Edit {
+		args = append(args, "--edit")
+	}
+	if o.Sign {
+		args = append(args, "--gpg-sign")
+	}
+	if o.Signoff {
+		args = append(args, "--signoff")
+	}
+	if o.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	if o.Author != "" {
+		args = append(args, "--author="+o.Author)
+	}
+	if o.Quiet {
+		args = append(args, "--quiet")
+	}
+	return append(args, o.Extra...)
+}
+
+// messageFlags replace the generated message; scopeFlags make git commit
+// other content than the analyzed diff; historyFlags fold the commit into
+// an existing one, whose changes the message does not describe. Each maps
+// long options to their short forms.
+var (
+	messageFlags = map[string]string{"--message": "-m", "--file": "-F", "--reuse-message": "-C", "--reedit-message": "-c"}
+	scopeFlags   = map[string]string{"--all": "-a", "--include": "-i", "--only": "-o", "--patch": "-p", "--interactive": "", "--pathspec-from-file": "", "--pathspec-file-nul": ""}
+	historyFlags = map[string]string{"--amend": "", "--fixup": "", "--squash": ""}
+)
+
+// ValidateCommitFlags rejects forwarded flags that would replace the
+// generated message, or commit something other than the changes the message
+// was generated for: -a, -i, -o, -p, pathspec files, pathspecs, and
+// --amend, --fixup and --squash.
+func ValidateCommitFlags(flags []string) error {
+	for _, flag := range flags {
+		name, _, _ := strings.Cut(flag, "=")
+		switch {
+		case flag == "--" || !strings.HasPrefix(flag, "-"):
+			return fmt.Errorf("pathspecs cannot be forwarded (got %q); use gitcomm -- <paths> so the commit matches what was analyzed", flag)
+		case strings.HasPrefix(name, "--"):
+			// git accepts any unambiguous prefix of a long option.
+			for long := range messageFlags {
+				if len(name) > 2 && strings.HasPrefix(long, name) {
+					return fmt.Errorf("%s cannot be forwarded; gitcomm supplies the commit message", name)
+				}
+			}
+			for long := range scopeFlags {
+				if len(name) > 2 && strings.HasPrefix(long, name) {
+					return scopeFlagError(name)
+				}
+			}
+			for long := range historyFlags {
+				if len(name) > 2 && strings.HasPrefix(long, name) {
+					return fmt.Errorf("%s cannot be forwarded; the message only describes the staged changes (use gitcomm -amend to regenerate HEAD's message)", name)
+				}
+			}
+		default:
+			if err := validateShortFlags(flag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateShortFlags checks a cluster of short options such as -sa. The
+// rest of the cluster after an option that takes a value is its value.
+func validateShortFlags(flag string) error {
+	for _, c := range flag[1:] {
+		switch {
+		case strings.ContainsRune("mFCc", c):
+			return fmt.Errorf("-%c cannot be forwarded; gitcomm supplies the commit message", c)
+		case strings.ContainsRune("aiop", c):
+			return scopeFlagError("-" + string(c))
+		case strings.ContainsRune("tSu", c):
+			return nil
+		}
+	}
+	return nil
+}
+
+func scopeFlagError(name string) error {
+	return fmt.Errorf("%s cannot be forwarded; it would commit something other than the analyzed changes (use gitcomm -a or -- <paths>)", name)
+}
+
+// CommitError reports a `git commit` that ran but did not create a commit,
+// typically because a hook rejected it.
+type CommitError struct {
+	ExitCode int
+	// Output is what git and its hooks wrote to stderr; git sends hook
+	// output there too.
+	Output string
+	// Hooks lists the enabled hooks that can reject a commit.
+	Hooks []string
+	// Message is the message git was given, including any edits made in
+	// the editor.
+	Message string
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("git commit exited with status %d", e.ExitCode)
+}
+
+// savedMessageFile keeps the message of a failed commit for `gitcomm retry`.
+const savedMessageFile = "GITCOMM_SAVED_MSG"
+
+// Commit runs `git commit -F` with the message in a file, so it works with
+// --edit, signing and hooks. git's output, including hook output and the
+// editor, is connected to the terminal; stderr is also captured so a
+// failure can be explained afterwards.
+func (r *Repo) Commit(message string, opts CommitOptions) error {
+	path, err := r.GitPath(commitMessageFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimRight(message, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write commit message: %w", err)
+	}
+	defer os.Remove(path)
+
+	args := opts.args(path)
+	diag.Info("git", "running git commit", "args", strings.Join(args[3:], " "), "amend", opts.Amend)
+	var stderr bytes.Buffer
+	cmd := r.command(args...)
+	// gitcomm's prompts read stdin through a buffer, so git only gets the
+	// terminal when it opens the editor, which needs it.
+	if opts.Edit {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		commitErr := &CommitError{
+			ExitCode: exitErr.ExitCode(),
+			Output:   strings.TrimSpace(stderr.String()),
+			Message:  message,
+		}
+		if !opts.NoVerify {
+			commitErr.Hooks = r.enabledHooks("pre-commit", "commit-msg")
+		}
+		if opts.Edit {
+			if edited := r.editedMessage(); edited != "" {
+				commitErr.Message = edited
+			}
+		}
+		diag.Error("git", "git commit failed", "status", commitErr.ExitCode, "hooks", strings.Join(commitErr.Hooks, ","), "output", diag.Snippet(commitErr.Output, 300))
+		return commitErr
+	}
+	return nil
 }
 
-func Push() error {
-	cmd := exec.Command("git", "push")
-	return cmd.Run()
+// enabledHooks returns which of names exist as executable hooks.
+func (r *Repo) enabledHooks(names ...string) []string {
+	dir, err := r.HooksDir()
+	if err != nil {
+		return nil
+	}
+	var enabled []string
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+			enabled = append(enabled, name)
+		}
+	}
+	return enabled
+}
+
+// editedMessage returns the message last written in git's editor with
+// comments removed, or "" when there is none.
+func (r *Repo) editedMessage() string {
+	path, err := r.GitPath("COMMIT_EDITMSG")
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	cmd := r.command("stripspace", "--strip-comments")
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// SaveMessage stores message in the git directory and returns the file's
+// path, so a commit that failed can be retried without regenerating it.
+func SaveMessage(message string) (string, error) {
+	path, err := GitPath(savedMessageFile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimRight(message, "\n")+"\n"), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SavedMessage returns the message stored by SaveMessage, or "" when there
+// is none.
+func SavedMessage() (string, error) {
+	path, err := GitPath(savedMessageFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ClearSavedMessage removes the message stored by SaveMessage.
+func ClearSavedMessage() error {
+	path, err := GitPath(savedMessageFile)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// HasHead reports whether the repository has at least one commit.
+func HasHead() bool {
+	return current.HasHead()
+}
+
+// AmendBase returns the revision an amended HEAD is compared against: HEAD^
+// normally, or the empty tree when HEAD is the root commit.
+func AmendBase() (string, error) {
+	if _, err := runGitQuiet("rev-parse", "--verify", "--quiet", "HEAD^"); err == nil {
+		return "HEAD^", nil
+	}
+	return EmptyTree()
+}
+
+// EmptyTree returns the id of the empty tree in the repository's hash format.
+func EmptyTree() (string, error) {
+	return current.emptyTree()
+}
+
+func (r *Repo) emptyTree() (string, error) {
+	out, err := r.run("hash-object", "-t", "tree", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// PublishedIn returns the remote-tracking ref that already contains rev,
+// preferring the branch's upstream, or "" when rev has not been pushed.
+func PublishedIn(rev string) (string, error) {
+	if upstream, err := runGitQuiet("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+		upstream = strings.TrimSpace(upstream)
+		if _, err := runGitQuiet("merge-base", "--is-ancestor", rev, upstream); err == nil {
+			return upstream, nil
+		}
+	}
+	out, err := runGit("branch", "--remotes", "--contains", rev, "--format=%(refname:short)")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+	}
+	return "", nil
 }
 
 func countLines(s string) int {
@@ -108,6 +700,14 @@ func splitDiffLines(s string) []string {
 	return strings.Split(trimmed, "\n")
 }
 
+// Pluralize returns singular when n is 1 and plural otherwise.
+func Pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
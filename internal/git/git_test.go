@@ -29,3 +29,30 @@ func TestLimitDiffSizeWithInfoExactLimitWithTrailingNewline(t *testing.T) {
 		t.Fatalf("expected 2 lines, got %d", countLines(diff))
 	}
 }
+
+func TestLimitDiffSizeWithInfoCutsAtLineBudgetAcrossFiles(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/a.go b/a.go",
+		"--- a/a.go",
+		"+++ b/a.go",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+		"diff --git a/b.go b/b.go",
+		"--- a/b.go",
+		"+++ b/b.go",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+	}, "\n") + "\n"
+	got, truncated := limitDiffSizeWithInfo(diff, 8)
+	if !truncated {
+		t.Fatal("expected truncation")
+	}
+	if !strings.HasPrefix(got, "diff --git a/a.go b/a.go\n") || !strings.HasSuffix(got, "--- a/b.go\n... (truncated, 4 more lines)") {
+		t.Fatalf("unexpected result: %q", got)
+	}
+	if !strings.HasSuffix(got, "... (truncated, 4 more lines)") {
+		t.Fatalf("missing truncation marker: %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+// PushFailure classifies why a push was rejected.
+type PushFailure int
+
+const (
+	PushFailed PushFailure = iota
+	// PushNonFastForward means the remote branch has commits the local
+	// branch does not.
+	PushNonFastForward
+	// PushStaleLease means --force-with-lease refused because the remote
+	// branch moved since it was last fetched.
+	PushStaleLease
+)
+
+// PushOptions controls Push. With no Remote, plain `git push` is run so the
+// branch's upstream and push.default decide where it goes.
+type PushOptions struct {
+	Remote string
+	Branch string
+	// SetUpstream records Remote/Branch as the upstream (-u).
+	SetUpstream    bool
+	ForceWithLease bool
+}
+
+func (o PushOptions) args() []string {
+	args := []string{"push"}
+	if o.SetUpstream {
+		args = append(args, "--set-upstream")
+	}
+	if o.ForceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	if o.Remote != "" {
+		args = append(args, o.Remote)
+		if o.Branch != "" {
+			args = append(args, o.Branch)
+		}
+	}
+	return args
+}
+
+// PushError reports a failed push together with git's stderr.
+type PushError struct {
+	Reason   PushFailure
+	ExitCode int
+	Output   string
+}
+
+func (e *PushError) Error() string {
+	switch e.Reason {
+	case PushNonFastForward:
+		return "the remote branch has commits that are not in your branch"
+	case PushStaleLease:
+		return "--force-with-lease refused: the remote branch changed since it was last fetched"
+	}
+	if line := firstErrorLine(e.Output); strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+		return line
+	}
+	return fmt.Sprintf("git push exited with status %d", e.ExitCode)
+}
+
+// Push runs git push, streaming its output to the terminal, and returns a
+// *PushError describing any rejection.
+func (r *Repo) Push(opts PushOptions) error {
+	args := opts.args()
+	diag.Info("git", "running git push", "args", strings.Join(args[1:], " "))
+	var stderr bytes.Buffer
+	cmd := r.command(args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		pushErr := &PushError{
+			Reason:   classifyPushFailure(stderr.String()),
+			ExitCode: exitErr.ExitCode(),
+			Output:   strings.TrimSpace(stderr.String()),
+		}
+		diag.Error("git", "git push failed", "reason", pushErr.Error(), "output", diag.Snippet(pushErr.Output, 300))
+		return pushErr
+	}
+	return nil
+}
+
+func classifyPushFailure(output string) PushFailure {
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, "[rejected]") && !strings.Contains(line, "[remote rejected]") {
+			continue
+		}
+		switch {
+		case strings.Contains(line, "(stale info)"):
+			return PushStaleLease
+		case strings.Contains(line, "(non-fast-forward)") || strings.Contains(line, "(fetch first)"):
+			return PushNonFastForward
+		}
+	}
+	return PushFailed
+}
+
+// Upstream returns the remote and branch name the given local branch
+// tracks, and whether it has an upstream at all.
+func Upstream(branch string) (remote, merge string, ok bool) {
+	remote = ConfigValue("branch." + branch + ".remote")
+	merge = strings.TrimPrefix(ConfigValue("branch."+branch+".merge"), "refs/heads/")
+	if remote == "" || merge == "" {
+		return "", "", false
+	}
+	return remote, merge, true
+}
+
+// Remotes lists the configured remotes.
+func Remotes() ([]string, error) {
+	out, err := runGit("remote")
+	if err != nil {
+		return nil, err
+	}
+	var remotes []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			remotes = append(remotes, line)
+		}
+	}
+	return remotes, nil
+}
+
+// DefaultPushRemote picks the remote git would push branch to without an
+// upstream: branch.<name>.pushRemote, remote.pushDefault, then origin or the
+// only remote. It returns "" when the choice is ambiguous.
+func DefaultPushRemote(branch string, remotes []string) string {
+	for _, key := range []string{"branch." + branch + ".pushRemote", "remote.pushDefault"} {
+		if remote := ConfigValue(key); remote != "" {
+			return remote
+		}
+	}
+	for _, remote := range remotes {
+		if remote == "origin" {
+			return remote
+		}
+	}
+	if len(remotes) == 1 {
+		return remotes[0]
+	}
+	return ""
+}
+
+// PullRebase runs `git pull --rebase --autostash remote branch`, streaming
+// its output. Unstaged changes are stashed and restored around the rebase.
+func PullRebase(remote, branch string) error {
+	args := []string{"pull", "--rebase", "--autostash", remote, branch}
+	diag.Info("git", "running git pull --rebase", "remote", remote, "branch", branch)
+	var stderr bytes.Buffer
+	cmd := current.command(args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := cmd.Run(); err != nil {
+		msg := firstErrorLine(stderr.String())
+		diag.Error("git", "git pull --rebase failed", "error", err, "output", diag.Snippet(msg, 300))
+		if RebaseInProgress() {
+			return fmt.Errorf("the rebase stopped with conflicts; resolve them and run `git rebase --continue`, or `git rebase --abort`")
+		}
+		if msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
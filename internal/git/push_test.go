@@ -0,0 +1,93 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPushSetsUpstreamAndHandlesRejections(t *testing.T) {
+	dir := newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	gitRun(t, "init", "-q", "--bare", "-b", "main", remote)
+	gitRun(t, "remote", "add", "origin", remote)
+
+	if _, _, ok := Upstream("main"); ok {
+		t.Fatal("new branch should have no upstream")
+	}
+	remotes, err := Remotes()
+	if err != nil || len(remotes) != 1 || DefaultPushRemote("main", remotes) != "origin" {
+		t.Fatalf("Remotes() = %v, %v", remotes, err)
+	}
+	if err := Default().Push(PushOptions{Remote: "origin", Branch: "main", SetUpstream: true}); err != nil {
+		t.Fatalf("Push(-u) error = %v", err)
+	}
+	if r, b, ok := Upstream("main"); !ok || r != "origin" || b != "main" {
+		t.Fatalf("Upstream() = %q, %q, %v", r, b, ok)
+	}
+
+	// Someone else pushes from another clone.
+	other := filepath.Join(t.TempDir(), "other")
+	gitRun(t, "clone", "-q", remote, other)
+	if err := os.Chdir(other); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "b.txt", "theirs\n")
+	gitRun(t, "add", "b.txt")
+	gitRun(t, "-c", "user.name=Other", "-c", "user.email=other@example.com", "commit", "-qm", "theirs")
+	gitRun(t, "push", "-q")
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, "a.txt", "one\ntwo\n")
+	gitRun(t, "commit", "-qam", "ours")
+	err = Default().Push(PushOptions{})
+	var pushErr *PushError
+	if !errors.As(err, &pushErr) || pushErr.Reason != PushNonFastForward || pushErr.Output == "" {
+		t.Fatalf("expected a non-fast-forward PushError, got %v", err)
+	}
+
+	writeFile(t, "a.txt", "one\ntwo\nunstaged\n")
+	if err := PullRebase("origin", "main"); err != nil {
+		t.Fatalf("PullRebase() error = %v", err)
+	}
+	if got := gitRun(t, "log", "--format=%s"); got != "ours\ntheirs\nfirst" {
+		t.Fatalf("unexpected history after rebase:\n%s", got)
+	}
+	if data, _ := os.ReadFile("a.txt"); string(data) != "one\ntwo\nunstaged\n" {
+		t.Fatalf("unstaged change should survive the rebase, got %q", data)
+	}
+	if err := Default().Push(PushOptions{}); err != nil {
+		t.Fatalf("Push() after rebase error = %v", err)
+	}
+
+	gitRun(t, "commit", "-q", "--amend", "-m", "ours, amended")
+	if err := Default().Push(PushOptions{}); !errors.As(err, &pushErr) || pushErr.Reason != PushNonFastForward {
+		t.Fatalf("pushing an amended commit should be rejected, got %v", err)
+	}
+	if err := Default().Push(PushOptions{ForceWithLease: true}); err != nil {
+		t.Fatalf("Push(--force-with-lease) error = %v", err)
+	}
+	if got := gitRun(t, "--git-dir", remote, "log", "-1", "--format=%s", "main"); got != "ours, amended" {
+		t.Fatalf("remote not updated, got %q", got)
+	}
+}
+
+func TestClassifyPushFailure(t *testing.T) {
+	cases := map[string]PushFailure{
+		" ! [rejected]        main -> main (fetch first)\nerror: failed to push some refs": PushNonFastForward,
+		" ! [rejected]        main -> main (non-fast-forward)":                             PushNonFastForward,
+		" ! [rejected]        main -> main (stale info)":                                   PushStaleLease,
+		"fatal: 'nowhere' does not appear to be a git repository":                          PushFailed,
+	}
+	for output, want := range cases {
+		if got := classifyPushFailure(output); got != want {
+			t.Errorf("classifyPushFailure(%q) = %v, want %v", output, got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+// Repo is the repository git commands run against, and how git is run.
+// The zero value runs "git" in the process's working directory with the
+// process's environment, so GIT_DIR and GIT_WORK_TREE are honoured.
+type Repo struct {
+	// Dir is the directory git runs in; "" means the working directory.
+	Dir string
+	// Git is the git binary; "" means "git" from PATH.
+	Git string
+	// Env holds extra KEY=value entries added to the process environment.
+	Env []string
+	// Config holds name=value settings passed to every command with -c.
+	Config []string
+}
+
+// current is the repository the package-level helpers use.
+var current = &Repo{}
+
+// Default returns the repository the package-level helpers run against.
+func Default() *Repo {
+	return current
+}
+
+// SetDefault makes r the repository the package-level helpers run against.
+func SetDefault(r *Repo) {
+	current = r
+}
+
+// Open returns a Repo for the repository containing dir, like `git -C dir`.
+func Open(dir string) (*Repo, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return nil, fmt.Errorf("cannot change to %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("cannot change to %s: not a directory", dir)
+	}
+	repo := &Repo{Dir: abs}
+	if _, err := repo.run("rev-parse", "--git-dir"); err != nil {
+		return nil, err
+	}
+	diag.Info("git", "opened repository", "dir", abs)
+	return repo, nil
+}
+
+func (r *Repo) binary() string {
+	if r.Git != "" {
+		return r.Git
+	}
+	return "git"
+}
+
+// command builds a git command with the repository's directory, config and
+// environment. Callers may append to cmd.Env.
+func (r *Repo) command(args ...string) *exec.Cmd {
+	full := make([]string, 0, 2*len(r.Config)+len(args))
+	for _, setting := range r.Config {
+		full = append(full, "-c", setting)
+	}
+	full = append(full, args...)
+	cmd := exec.Command(r.binary(), full...)
+	cmd.Dir = r.Dir
+	cmd.Env = append(os.Environ(), r.Env...)
+	return cmd
+}
+
+// run runs a git command and returns its stdout. Failures are logged and
+// reported with git's error line.
+func (r *Repo) run(args ...string) (string, error) {
+	cmd := r.command(args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		msg := firstErrorLine(stderr.String())
+		diag.Error("git", "git command failed", "args", strings.Join(args, " "), "error", err, "output", diag.Snippet(msg, 300))
+		if msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// runQuiet is run for probes whose failure is an expected answer, so it
+// does not log errors.
+func (r *Repo) runQuiet(args ...string) (string, error) {
+	output, err := r.command(args...).Output()
+	return string(output), err
+}
+
+// runEnv runs a git command with extra environment and stdin, returning
+// git's error line on failure.
+func (r *Repo) runEnv(env []string, stdin string, args ...string) error {
+	cmd := r.command(args...)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := firstErrorLine(string(output))
+		diag.Error("git", "git command failed", "args", strings.Join(args, " "), "error", err, "output", diag.Snippet(msg, 300))
+		if msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// abs resolves a path git printed relative to the directory it ran in.
+func (r *Repo) abs(path string) (string, error) {
+	if !filepath.IsAbs(path) && r.Dir != "" {
+		path = filepath.Join(r.Dir, path)
+	}
+	return filepath.Abs(path)
+}
+
+// Root returns the top-level directory of the work tree.
+func (r *Repo) Root() (string, error) {
+	out, err := r.run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// GitPath returns the absolute path of name inside the git directory. In a
+// linked worktree, per-worktree files such as COMMIT_EDITMSG resolve to the
+// worktree's own directory.
+func (r *Repo) GitPath(name string) (string, error) {
+	out, err := r.run("rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	return r.abs(strings.TrimSpace(out))
+}
+
+// HasHead reports whether the repository has at least one commit.
+func (r *Repo) HasHead() bool {
+	_, err := r.runQuiet("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
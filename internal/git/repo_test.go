@@ -0,0 +1,781 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/ignore"
+)
+
+// newTestRepo creates an empty repository in a temp dir and makes it the
+// working directory for the rest of the test.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, ".gitconfig-test"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	gitRun(t, "init", "-q", "-b", "main")
+	gitRun(t, "config", "user.name", "Test")
+	gitRun(t, "config", "user.email", "test@example.com")
+	gitRun(t, "config", "commit.gpgsign", "false")
+	return dir
+}
+
+func gitRun(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAmendBaseHandlesRootCommit(t *testing.T) {
+	newTestRepo(t)
+	if HasHead() {
+		t.Fatal("expected empty repository to have no HEAD")
+	}
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "wip")
+
+	base, err := AmendBase()
+	if err != nil {
+		t.Fatalf("AmendBase() error = %v", err)
+	}
+	if base == "HEAD^" {
+		t.Fatal("root commit must be compared against the empty tree")
+	}
+
+	writeFile(t, "b.txt", "two\n")
+	gitRun(t, "add", "b.txt")
+	changes, err := Default().GetStagedChanges(DiffOptions{Base: base})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	if len(changes.Files) != 2 {
+		t.Fatalf("expected root commit plus staged file, got %+v", changes.Files)
+	}
+
+	if err := Default().Commit("Add a and b", CommitOptions{Amend: true}); err != nil {
+		t.Fatalf("Commit(amend) error = %v", err)
+	}
+	if got := gitRun(t, "log", "--format=%s"); got != "Add a and b" {
+		t.Fatalf("unexpected history after amend: %q", got)
+	}
+}
+
+func TestAmendBaseUsesParentAndPublishedIn(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	writeFile(t, "a.txt", "one\ntwo\n")
+	gitRun(t, "commit", "-qam", "wip")
+
+	base, err := AmendBase()
+	if err != nil || base != "HEAD^" {
+		t.Fatalf("AmendBase() = %q, %v", base, err)
+	}
+	if ref, err := PublishedIn("HEAD"); err != nil || ref != "" {
+		t.Fatalf("PublishedIn() = %q, %v; want unpublished", ref, err)
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	gitRun(t, "init", "-q", "--bare", remote)
+	gitRun(t, "remote", "add", "origin", remote)
+	gitRun(t, "push", "-q", "-u", "origin", "main")
+
+	ref, err := PublishedIn("HEAD")
+	if err != nil {
+		t.Fatalf("PublishedIn() error = %v", err)
+	}
+	if ref != "origin/main" {
+		t.Fatalf("expected origin/main, got %q", ref)
+	}
+}
+
+func TestRewriteMessagesPreservesTreesAndAuthors(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "root")
+	for i, content := range []string{"one\ntwo\n", "one\ntwo\nthree\n", "one\ntwo\nthree\nfour\n"} {
+		writeFile(t, "a.txt", content)
+		gitRun(t, "add", "a.txt")
+		gitRun(t, "-c", "user.name=Other", "commit", "-qm", "wip "+string(rune('1'+i)), "--date=2001-02-03T04:05:06Z")
+	}
+	oldTip := gitRun(t, "rev-parse", "HEAD")
+	oldTrees := gitRun(t, "log", "--format=%T %an %ad", "HEAD~3..HEAD")
+
+	base, head, err := ResolveRange("HEAD~3..")
+	if err != nil {
+		t.Fatalf("ResolveRange() error = %v", err)
+	}
+	if head != oldTip {
+		t.Fatalf("expected empty head to resolve to HEAD")
+	}
+	commits, err := ListCommits(base, head)
+	if err != nil || len(commits) != 3 {
+		t.Fatalf("ListCommits() = %+v, %v", commits, err)
+	}
+	if commits[0].Subject != "wip 1" || commits[2].Subject != "wip 3" {
+		t.Fatalf("expected oldest first, got %q .. %q", commits[0].Subject, commits[2].Subject)
+	}
+	if n, err := UnpublishedCount(base, head); err != nil || n != 3 {
+		t.Fatalf("UnpublishedCount() = %d, %v", n, err)
+	}
+
+	newTip, err := RewriteMessages(commits, map[string]string{commits[1].Hash: "Add line three\n\nExplain why."})
+	if err != nil {
+		t.Fatalf("RewriteMessages() error = %v", err)
+	}
+	if newTip == oldTip || gitRun(t, "rev-parse", "HEAD") != newTip {
+		t.Fatalf("HEAD not moved to new tip %s", newTip)
+	}
+	if got := gitRun(t, "rev-parse", "ORIG_HEAD"); got != oldTip {
+		t.Fatalf("ORIG_HEAD = %s, want %s", got, oldTip)
+	}
+	if got := gitRun(t, "log", "--format=%s", "HEAD~3..HEAD"); got != "wip 3\nAdd line three\nwip 1" {
+		t.Fatalf("unexpected subjects:\n%s", got)
+	}
+	if got := gitRun(t, "rev-parse", "HEAD~2"); got != commits[0].Hash {
+		t.Fatalf("commits before the first reworded one should be kept, got %s", got)
+	}
+	if got := gitRun(t, "log", "--format=%T %an %ad", "HEAD~3..HEAD"); got != oldTrees {
+		t.Fatalf("trees or authors changed:\n%s\nwant:\n%s", got, oldTrees)
+	}
+	if got := gitRun(t, "status", "--porcelain"); got != "" {
+		t.Fatalf("work tree should be untouched, got %q", got)
+	}
+}
+
+func TestListCommitsReadsMessagesAndParents(t *testing.T) {
+	newTestRepo(t)
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "root")
+	root := gitRun(t, "rev-parse", "HEAD")
+	gitRun(t, "checkout", "-qb", "topic")
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "Add topic\n\nExplain the topic.\n\nRefs: #1")
+	topic := gitRun(t, "rev-parse", "HEAD")
+	gitRun(t, "checkout", "-q", "-")
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "main work")
+	gitRun(t, "merge", "-q", "--no-ff", "-m", "Merge topic", "topic")
+
+	commits, err := ListCommits(root, "HEAD")
+	if err != nil || len(commits) != 3 {
+		t.Fatalf("ListCommits() = %+v, %v", commits, err)
+	}
+	var merge, added CommitInfo
+	for _, commit := range commits {
+		switch commit.Subject {
+		case "Merge topic":
+			merge = commit
+		case "Add topic":
+			added = commit
+		}
+	}
+	if commits[2].Hash != merge.Hash || len(merge.Parents) != 2 || merge.Parents[1] != topic {
+		t.Fatalf("expected the merge last with both parents, got %+v", commits)
+	}
+	if added.Message != "Add topic\n\nExplain the topic.\n\nRefs: #1" || len(added.Parents) != 1 || added.Parents[0] != root {
+		t.Fatalf("unexpected topic commit %+v", added)
+	}
+	if commits, err := ListCommits("HEAD", "HEAD"); err != nil || len(commits) != 0 {
+		t.Fatalf("ListCommits() over an empty range = %+v, %v", commits, err)
+	}
+}
+
+func TestRewriteMessagesRefusesMovedHead(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "root")
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "wip")
+	base, head, err := ResolveRange("HEAD~1..HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits, err := ListCommits(base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, "commit", "-q", "--allow-empty", "-m", "later")
+
+	if _, err := RewriteMessages(commits, map[string]string{commits[0].Hash: "Reworded"}); err == nil {
+		t.Fatal("expected an error when HEAD no longer matches the range")
+	}
+	if got := gitRun(t, "log", "-1", "--format=%s"); got != "later" {
+		t.Fatalf("HEAD should be unchanged, got %q", got)
+	}
+}
+
+func TestDefaultBaseBranchAndMergeBase(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	fork := gitRun(t, "rev-parse", "HEAD")
+	gitRun(t, "checkout", "-qb", "feature")
+	writeFile(t, "a.txt", "one\ntwo\n")
+	gitRun(t, "commit", "-qam", "feature work")
+	gitRun(t, "checkout", "-q", "main")
+	writeFile(t, "b.txt", "main\n")
+	gitRun(t, "add", "b.txt")
+	gitRun(t, "commit", "-qm", "main work")
+	gitRun(t, "checkout", "-q", "feature")
+
+	if branch, err := CurrentBranch(); err != nil || branch != "feature" {
+		t.Fatalf("CurrentBranch() = %q, %v", branch, err)
+	}
+	base, err := DefaultBaseBranch()
+	if err != nil || base != "main" {
+		t.Fatalf("DefaultBaseBranch() = %q, %v", base, err)
+	}
+	mergeBase, err := MergeBase(base, "HEAD")
+	if err != nil || mergeBase != fork {
+		t.Fatalf("MergeBase() = %q, %v; want %s", mergeBase, err, fork)
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	gitRun(t, "init", "-q", "--bare", "-b", "trunk", remote)
+	gitRun(t, "remote", "add", "origin", remote)
+	gitRun(t, "push", "-q", "origin", "main:trunk")
+	gitRun(t, "remote", "set-head", "origin", "trunk")
+	if base, err := DefaultBaseBranch(); err != nil || base != "origin/trunk" {
+		t.Fatalf("DefaultBaseBranch() with origin/HEAD = %q, %v", base, err)
+	}
+}
+
+func TestTagsAndChangedPaths(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	if tag := PreviousTag("HEAD"); tag != "" {
+		t.Fatalf("PreviousTag() on root = %q", tag)
+	}
+	gitRun(t, "tag", "v1.0.0")
+	writeFile(t, "b/c.txt", "two\n")
+	gitRun(t, "add", "b/c.txt")
+	gitRun(t, "commit", "-qm", "second")
+
+	if tag := PreviousTag("HEAD"); tag != "v1.0.0" {
+		t.Fatalf("PreviousTag() = %q", tag)
+	}
+	if tag := TagAt("HEAD"); tag != "" {
+		t.Fatalf("TagAt() on untagged commit = %q", tag)
+	}
+	gitRun(t, "tag", "v1.1.0")
+	if tag := TagAt("HEAD"); tag != "v1.1.0" {
+		t.Fatalf("TagAt() = %q", tag)
+	}
+	if tag := PreviousTag("HEAD"); tag != "v1.0.0" {
+		t.Fatalf("PreviousTag() should skip the tag at HEAD, got %q", tag)
+	}
+	if paths, err := ChangedPaths("HEAD"); err != nil || len(paths) != 1 || paths[0] != "b/c.txt" {
+		t.Fatalf("ChangedPaths() = %v, %v", paths, err)
+	}
+	if paths, err := ChangedPaths("v1.0.0"); err != nil || len(paths) != 1 || paths[0] != "a.txt" {
+		t.Fatalf("ChangedPaths() on root = %v, %v", paths, err)
+	}
+	if commits, err := ListCommits("", "HEAD"); err != nil || len(commits) != 2 {
+		t.Fatalf("ListCommits() over all history = %d, %v", len(commits), err)
+	}
+}
+
+func TestCommitPassesFlagsAndMessageFile(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+
+	message := "Add a.txt\n\n# Not a comment: cleanup keeps this line.\nBody."
+	opts := CommitOptions{
+		Signoff: true,
+		Author:  "Jane Doe <jane@example.com>",
+		Extra:   []string{"-q", "--date=2001-02-03T04:05:06Z"},
+	}
+	if err := Default().Commit(message, opts); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if got := gitRun(t, "log", "-1", "--format=%an <%ae> %ad", "--date=short"); got != "Jane Doe <jane@example.com> 2001-02-03" {
+		t.Fatalf("unexpected author: %q", got)
+	}
+	body := gitRun(t, "log", "-1", "--format=%B")
+	if !strings.HasPrefix(body, message) || !strings.HasSuffix(body, "Signed-off-by: Test <test@example.com>") {
+		t.Fatalf("unexpected message:\n%s", body)
+	}
+	if path, _ := GitPath(commitMessageFile); fileExists(path) {
+		t.Fatal("message file should be removed after committing")
+	}
+
+	gitRun(t, "config", "core.hooksPath", "hooks")
+	writeFile(t, "hooks/pre-commit", "#!/bin/sh\necho rejected >&2\nexit 1\n")
+	if err := os.Chmod("hooks/pre-commit", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "a.txt", "two\n")
+	gitRun(t, "add", "a.txt")
+	err := Default().Commit("Change a", CommitOptions{Extra: []string{"-q"}})
+	var commitErr *CommitError
+	if !errors.As(err, &commitErr) {
+		t.Fatalf("expected a CommitError, got %v", err)
+	}
+	if commitErr.ExitCode != 1 || commitErr.Output != "rejected" || commitErr.Message != "Change a" {
+		t.Fatalf("unexpected CommitError: %+v", commitErr)
+	}
+	if len(commitErr.Hooks) != 1 || commitErr.Hooks[0] != "pre-commit" {
+		t.Fatalf("expected the pre-commit hook to be named, got %v", commitErr.Hooks)
+	}
+	if err := Default().Commit("Change a", CommitOptions{NoVerify: true, Extra: []string{"-q"}}); err != nil {
+		t.Fatalf("Commit(no-verify) error = %v", err)
+	}
+}
+
+func TestCommitterIdentHonoursEnvironment(t *testing.T) {
+	newTestRepo(t)
+	if name, email, err := CommitterIdent(); err != nil || name != "Test" || email != "test@example.com" {
+		t.Fatalf("CommitterIdent() = %q, %q, %v", name, email, err)
+	}
+	t.Setenv("GIT_COMMITTER_NAME", "Other Name")
+	t.Setenv("GIT_COMMITTER_EMAIL", "other@example.com")
+	if name, email, err := CommitterIdent(); err != nil || name != "Other Name" || email != "other@example.com" {
+		t.Fatalf("CommitterIdent() = %q, %q, %v; want the environment's identity", name, email, err)
+	}
+}
+
+func TestSavedMessageRoundTrip(t *testing.T) {
+	newTestRepo(t)
+	if got, err := SavedMessage(); err != nil || got != "" {
+		t.Fatalf("SavedMessage() = %q, %v; want nothing saved", got, err)
+	}
+	path, err := SaveMessage("Fix parser\n\nHandle empty input.\n\n")
+	if err != nil {
+		t.Fatalf("SaveMessage() error = %v", err)
+	}
+	if !filepath.IsAbs(path) || !fileExists(path) {
+		t.Fatalf("expected an absolute path to the saved message, got %q", path)
+	}
+	if got, _ := SavedMessage(); got != "Fix parser\n\nHandle empty input." {
+		t.Fatalf("SavedMessage() = %q", got)
+	}
+	if err := ClearSavedMessage(); err != nil {
+		t.Fatalf("ClearSavedMessage() error = %v", err)
+	}
+	if fileExists(path) {
+		t.Fatal("saved message should be removed")
+	}
+	if err := ClearSavedMessage(); err != nil {
+		t.Fatalf("ClearSavedMessage() without a saved message: %v", err)
+	}
+}
+
+func TestRecentMessagesFiltersByPathAndSkipsMerges(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "Add a\n\nWith a body.")
+	writeFile(t, "b.txt", "one\n")
+	gitRun(t, "add", "b.txt")
+	gitRun(t, "commit", "-qm", "Add b")
+	gitRun(t, "checkout", "-qb", "side")
+	writeFile(t, "a.txt", "two\n")
+	gitRun(t, "commit", "-qam", "Change a")
+	gitRun(t, "checkout", "-q", "main")
+	gitRun(t, "merge", "-q", "--no-ff", "-m", "Merge side", "side")
+
+	got, err := RecentMessages("HEAD", 10, nil)
+	if err != nil {
+		t.Fatalf("RecentMessages() error = %v", err)
+	}
+	// Commits made within the same second have no defined order.
+	sort.Strings(got)
+	if strings.Join(got, "|") != "Add a\n\nWith a body.|Add b|Change a" {
+		t.Fatalf("unexpected messages: %q", got)
+	}
+	if got, _ := RecentMessages("HEAD", 1, []string{"a.txt"}); len(got) != 1 || got[0] != "Change a" {
+		t.Fatalf("unexpected path-filtered messages: %q", got)
+	}
+	if got, _ := RecentMessages("HEAD~1", 10, []string{"b.txt"}); len(got) != 1 || got[0] != "Add b" {
+		t.Fatalf("unexpected messages before the merge: %q", got)
+	}
+}
+
+func TestValidateCommitFlagsRejectsMessageFlags(t *testing.T) {
+	for _, flag := range []string{"-m", "-mfoo", "--message=x", "-F", "--file=msg", "-C", "--reuse-message=HEAD", "--mess=x", "-sm"} {
+		if err := ValidateCommitFlags([]string{flag}); err == nil {
+			t.Fatalf("expected %s to be rejected", flag)
+		}
+	}
+	if err := ValidateCommitFlags([]string{"--date=now", "-S", "-Sabcdef", "--no-post-rewrite", "-s", "--porcelain"}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestValidateCommitFlagsRejectsScopeFlags(t *testing.T) {
+	for _, flags := range [][]string{
+		{"-a"}, {"--all"}, {"-sa"},
+		{"-i"}, {"--include"},
+		{"-o"}, {"--only"},
+		{"-p"}, {"--patch"}, {"--interactive"},
+		{"--pathspec-from-file=paths.txt"}, {"--pathspec-file-nul"},
+		{"src/a.go"},
+		{"--", "src/a.go"},
+		{"--date=now", "--"},
+		{"--amend"}, {"--am"},
+		{"--fixup=HEAD"}, {"--fixup=amend:HEAD"},
+		{"--squash=HEAD"},
+	} {
+		if err := ValidateCommitFlags(flags); err == nil {
+			t.Fatalf("expected %q to be rejected", flags)
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// testIdentity is the commit identity for repositories set up outside
+// newTestRepo's config.
+var testIdentity = []string{"user.name=Test", "user.email=test@example.com", "commit.gpgsign=false"}
+
+func TestRepoInLinkedWorktree(t *testing.T) {
+	mainDir := newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	wt := filepath.Join(t.TempDir(), "wt")
+	gitRun(t, "worktree", "add", "-q", "-b", "feature", wt)
+
+	// The process stays in the main work tree; only the Repo points at the
+	// linked one.
+	repo, err := Open(wt)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if root, err := repo.Root(); err != nil || !sameDir(t, root, wt) {
+		t.Fatalf("Root() = %q, %v; want %s", root, err, wt)
+	}
+	path, err := repo.GitPath("COMMIT_EDITMSG")
+	if err != nil || !strings.Contains(path, filepath.Join(".git", "worktrees", "wt")) || !filepath.IsAbs(path) {
+		t.Fatalf("GitPath() = %q, %v; want an absolute path in the worktree's git dir", path, err)
+	}
+
+	writeFile(t, filepath.Join(wt, "b.txt"), "two\n")
+	if err := repo.StageAll(); err != nil {
+		t.Fatalf("StageAll() error = %v", err)
+	}
+	changes, err := repo.GetStagedChanges(DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	if len(changes.Files) != 1 || changes.Files[0].Path != "b.txt" {
+		t.Fatalf("expected b.txt staged in the worktree, got %+v", changes.Files)
+	}
+	if err := repo.Commit("Add b", CommitOptions{Extra: []string{"-q"}}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if got := gitRun(t, "log", "-1", "--format=%s", "feature"); got != "Add b" {
+		t.Fatalf("expected the commit on feature, got %q", got)
+	}
+	if got := gitRun(t, "-C", mainDir, "log", "-1", "--format=%s", "main"); got != "first" {
+		t.Fatalf("main should be untouched, got %q", got)
+	}
+}
+
+func TestRepoWithGitDirAndWorkTreeEnv(t *testing.T) {
+	newTestRepo(t)
+	base := t.TempDir()
+	gitDir := filepath.Join(base, "meta.git")
+	workTree := filepath.Join(base, "files")
+	gitRun(t, "init", "-q", "-b", "main", "--separate-git-dir", gitDir, workTree)
+	// Without the .git link the repository is only reachable via GIT_DIR.
+	if err := os.Remove(filepath.Join(workTree, ".git")); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &Repo{
+		Dir:    workTree,
+		Env:    []string{"GIT_DIR=" + gitDir, "GIT_WORK_TREE=" + workTree},
+		Config: testIdentity,
+	}
+	if root, err := repo.Root(); err != nil || !sameDir(t, root, workTree) {
+		t.Fatalf("Root() = %q, %v; want %s", root, err, workTree)
+	}
+	writeFile(t, filepath.Join(workTree, "a.txt"), "one\n")
+	if err := repo.StageAll(); err != nil {
+		t.Fatalf("StageAll() error = %v", err)
+	}
+	changes, err := repo.GetStagedChanges(DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	if len(changes.Files) != 1 || changes.Files[0].Path != "a.txt" {
+		t.Fatalf("expected a.txt staged, got %+v", changes.Files)
+	}
+	if err := repo.Commit("Add a", CommitOptions{Extra: []string{"-q"}}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if got := gitRun(t, "--git-dir", gitDir, "log", "--format=%s %an"); got != "Add a Test" {
+		t.Fatalf("unexpected history %q", got)
+	}
+	if got := gitRun(t, "log", "--all", "--format=%s"); got != "" {
+		t.Fatalf("the process's own repository should be untouched, got %q", got)
+	}
+}
+
+func TestRepoWithoutCommits(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if repo.HasHead() {
+		t.Fatal("expected no HEAD in a new repository")
+	}
+	writeFile(t, "a.txt", "one\n")
+	if err := repo.StageAll(); err != nil {
+		t.Fatalf("StageAll() error = %v", err)
+	}
+	changes, err := repo.GetStagedChanges(DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	if len(changes.Files) != 1 || !strings.Contains(changes.Diff, "+one") {
+		t.Fatalf("expected the first file staged, got %+v", changes)
+	}
+	if err := repo.Commit("Initial commit", CommitOptions{Extra: []string{"-q"}}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	root, err := repo.GetCommitChanges("HEAD", DiffOptions{})
+	if err != nil || len(root.Files) != 1 {
+		t.Fatalf("GetCommitChanges(root) = %+v, %v", root, err)
+	}
+}
+
+func TestOpenRejectsNonRepository(t *testing.T) {
+	newTestRepo(t)
+	if _, err := Open(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Fatal("expected an error outside a repository")
+	}
+}
+
+// sameDir compares directories after resolving symlinks, since temp dirs
+// may be reached through one.
+func sameDir(t *testing.T, a, b string) bool {
+	t.Helper()
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+func TestDiffOptionsShapeThePatch(t *testing.T) {
+	newTestRepo(t)
+	original := "package a\n\nfunc A() {\n\tone()\n\ttwo()\n\tthree()\n\tfour()\n\tfive()\n}\n"
+	writeFile(t, "a.go", original)
+	writeFile(t, "c.txt", "c\n")
+	writeFile(t, "old.txt", strings.Repeat("line\n", 20))
+	gitRun(t, "add", ".")
+	gitRun(t, "commit", "-qm", "first")
+
+	writeFile(t, "a.go", strings.Replace(original, "\tone()", "    one()", 1))
+	writeFile(t, "copy.go", original)
+	writeFile(t, "c.txt", "c\n\n\n")
+	gitRun(t, "mv", "old.txt", "new.txt")
+	gitRun(t, "add", ".")
+
+	collect := func(opts DiffOptions) *StagedChanges {
+		t.Helper()
+		changes, err := Default().GetStagedChanges(opts)
+		if err != nil {
+			t.Fatalf("GetStagedChanges(%+v) error = %v", opts, err)
+		}
+		return changes
+	}
+
+	changes := collect(DiffOptions{})
+	if !strings.Contains(changes.Diff, "rename from old.txt") || strings.Contains(changes.Diff, "copy from") {
+		t.Fatalf("renames but not copies should be detected by default:\n%s", changes.Diff)
+	}
+	if strings.Contains(changes.Diff, " \tfive()") {
+		t.Fatalf("expected git's default context:\n%s", changes.Diff)
+	}
+	if changes = collect(DiffOptions{NoRenames: true}); strings.Contains(changes.Diff, "rename from") || len(changes.Files) != 5 {
+		t.Fatalf("expected a deletion and an addition without renames, got %+v", changes.Files)
+	}
+	if changes = collect(DiffOptions{FindCopies: true}); !strings.Contains(changes.Diff, "copy from a.go") {
+		t.Fatalf("expected copy.go to be detected as a copy:\n%s", changes.Diff)
+	}
+
+	changes = collect(DiffOptions{IgnoreBlankLines: true})
+	if strings.Contains(changes.Diff, "diff --git a/c.txt") || !strings.Contains(changes.Diff, "+    one()") {
+		t.Fatalf("only the blank-line change should be left out:\n%s", changes.Diff)
+	}
+	changes = collect(DiffOptions{IgnoreWhitespace: true, IgnoreBlankLines: true})
+	if strings.Contains(changes.Diff, "diff --git a/a.go") {
+		t.Fatalf("the whitespace change should be left out with -w:\n%s", changes.Diff)
+	}
+	if len(changes.Files) != 4 {
+		t.Fatalf("the file summary should still list every changed file, got %+v", changes.Files)
+	}
+
+	one, zero := 1, 0
+	if changes = collect(DiffOptions{Context: &one}); !strings.Contains(changes.Diff, "@@ -3,3 +3,3 @@") {
+		t.Fatalf("expected one line of context:\n%s", changes.Diff)
+	}
+	if changes = collect(DiffOptions{Context: &zero}); !strings.Contains(changes.Diff, "@@ -4 +4 @@") {
+		t.Fatalf("expected no context with -U0:\n%s", changes.Diff)
+	}
+	if changes = collect(DiffOptions{FunctionContext: true, Algorithm: "histogram"}); !strings.Contains(changes.Diff, " \tfive()") {
+		t.Fatalf("expected the whole function as context:\n%s", changes.Diff)
+	}
+}
+
+func TestIgnoreWhitespaceAppliesToFileStats(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\ntwo\n")
+	writeFile(t, "b.txt", "one\ntwo\nthree\n")
+	gitRun(t, "add", ".")
+	gitRun(t, "commit", "-qm", "first")
+	writeFile(t, "a.txt", "one  \n\ttwo\n")
+	writeFile(t, "b.txt", "  one\ntwo\nfour\n")
+	gitRun(t, "add", ".")
+
+	changes, err := Default().GetStagedChanges(DiffOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := map[string]gitdiff.FileStat{}
+	for _, file := range changes.Files {
+		stats[file.Path] = file
+	}
+	if a := stats["a.txt"]; a.Added != 0 || a.Removed != 0 || a.Omitted != "only whitespace changes" {
+		t.Fatalf("a.txt should be counted as whitespace only, got %+v", a)
+	}
+	if b := stats["b.txt"]; b.Added != 1 || b.Removed != 1 || b.Omitted != "" {
+		t.Fatalf("b.txt should count only the real change, got %+v", b)
+	}
+
+	changes, err = Default().GetStagedChanges(DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range changes.Files {
+		if file.Omitted != "" || file.Added != 2 {
+			t.Fatalf("without -w every line counts, got %+v", changes.Files)
+		}
+	}
+}
+
+func TestChangesFromPatchSummarizesWithoutTheIndex(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, ".gitattributes", "gen/** linguist-generated\n")
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", ".")
+	gitRun(t, "commit", "-qm", "first")
+	writeFile(t, "a.txt", "one\ntwo\n")
+	writeFile(t, "gen/api.txt", "generated\n")
+	writeFile(t, "docs/notes.md", "notes\n")
+	gitRun(t, "add", ".")
+	gitRun(t, "commit", "-qm", "second")
+	patch := gitRun(t, "diff", "HEAD^", "HEAD") + "\n"
+
+	// Nothing is staged; everything comes from the patch text.
+	var progress bytes.Buffer
+	changes := Default().ChangesFromPatch(patch, DiffOptions{Exclude: ignore.New([]string{"docs/"}), Progress: &progress})
+	if got := progress.String(); !strings.HasPrefix(got, "📄 Analyzed ") || !strings.Contains(got, "excluded 7 lines from 1 file") {
+		t.Fatalf("unexpected progress line %q", got)
+	}
+	if !strings.Contains(changes.Diff, "+two") || strings.Contains(changes.Diff, "+notes") || strings.Contains(changes.Diff, "+generated") {
+		t.Fatalf("unexpected diff:\n%s", changes.Diff)
+	}
+	if changes.ExcludedFiles != 1 || changes.CollapsedFiles != 1 {
+		t.Fatalf("expected one excluded and one collapsed file, got %+v", changes)
+	}
+	omitted := map[string]string{}
+	for _, file := range changes.Files {
+		omitted[file.Path] = file.Omitted
+	}
+	want := map[string]string{
+		"a.txt":         "",
+		"docs/notes.md": "excluded by ignore patterns",
+		"gen/api.txt":   "generated file (linguist-generated)",
+	}
+	if len(omitted) != len(want) {
+		t.Fatalf("unexpected files %+v", changes.Files)
+	}
+	for path, reason := range want {
+		if got, ok := omitted[path]; !ok || got != reason {
+			t.Fatalf("file %s omitted = %q, want %q (%+v)", path, got, reason, changes.Files)
+		}
+	}
+
+	// Outside a repository the patch is still prepared, without attributes.
+	outside := &Repo{Dir: t.TempDir()}
+	changes = outside.ChangesFromPatch(patch, DiffOptions{})
+	if len(changes.Files) != 3 || changes.CollapsedFiles != 0 || !strings.Contains(changes.Diff, "+generated") {
+		t.Fatalf("unexpected changes outside a repository: %+v", changes)
+	}
+}
+
+func TestStagedChangesListChangedSymbols(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.go", "package a\n\nfunc Keep() {}\n\nfunc Change() int { return 1 }\n\nfunc Drop() {}\n")
+	body := strings.Repeat("    x = 1\n", 10)
+	writeFile(t, "old.py", "def load():\n"+body+"    return 1\n")
+	writeFile(t, "vendor/v.go", "package v\n\nfunc V() {}\n")
+	gitRun(t, "add", ".")
+	gitRun(t, "commit", "-qm", "first")
+
+	writeFile(t, "a.go", "package a\n\nfunc Keep() {}\n\nfunc Change() int { return 2 }\n\ntype Added struct{}\n")
+	gitRun(t, "mv", "old.py", "new.py")
+	writeFile(t, "new.py", "def load():\n"+body+"    return 2\n")
+	writeFile(t, "vendor/v.go", "package v\n\nfunc W() {}\n")
+	gitRun(t, "add", ".")
+	// Unstaged edits are not part of the change.
+	writeFile(t, "a.go", "package a\n\nfunc Unstaged() {}\n")
+
+	changes, err := Default().GetStagedChanges(DiffOptions{Exclude: ignore.New([]string{"vendor/"})})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	var got []string
+	for _, change := range changes.Symbols {
+		got = append(got, change.Path+": "+change.String())
+	}
+	want := "a.go: modified func Change, a.go: added type Added, a.go: removed func Drop, new.py: modified func load"
+	if strings.Join(got, ", ") != want {
+		t.Fatalf("Symbols = %s\nwant %s", strings.Join(got, ", "), want)
+	}
+}
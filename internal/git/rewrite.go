@@ -0,0 +1,206 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+// CommitInfo describes an existing commit being considered for rewording.
+type CommitInfo struct {
+	Hash    string
+	Parents []string
+	Subject string
+	Message string
+}
+
+func (c CommitInfo) Short() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// ResolveRange resolves "<base>..<head>" to full commit ids. An empty head
+// ("<base>..") means HEAD.
+func ResolveRange(spec string) (string, string, error) {
+	baseSpec, headSpec, ok := strings.Cut(spec, "..")
+	if !ok || strings.HasPrefix(headSpec, ".") {
+		return "", "", fmt.Errorf("expected a range like <base>..<head>, got %q", spec)
+	}
+	if headSpec == "" {
+		headSpec = "HEAD"
+	}
+	base, err := ResolveCommit(baseSpec)
+	if err != nil {
+		return "", "", err
+	}
+	head, err := ResolveCommit(headSpec)
+	if err != nil {
+		return "", "", err
+	}
+	return base, head, nil
+}
+
+func ResolveCommit(rev string) (string, error) {
+	out, err := runGitQuiet("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ListCommits returns the commits in base..head, oldest first. An empty base
+// lists all of head's history.
+func ListCommits(base, head string) ([]CommitInfo, error) {
+	revs := head
+	if base != "" {
+		revs = base + ".." + head
+	}
+	// -z ends each commit with a NUL, and %x00 splits its ids from its
+	// message, so the output alternates between the two.
+	out, err := runGit("log", "-z", "--reverse", "--topo-order", "--format=%H %P%x00%B", revs)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("unexpected git log output for %s", revs)
+	}
+	commits := make([]CommitInfo, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		ids := strings.Fields(fields[i])
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("unexpected git log output for %s", revs)
+		}
+		message := strings.TrimRight(fields[i+1], "\n")
+		subject, _, _ := strings.Cut(message, "\n")
+		commits = append(commits, CommitInfo{Hash: ids[0], Parents: ids[1:], Subject: subject, Message: message})
+	}
+	return commits, nil
+}
+
+// RecentMessages returns up to n commit messages reachable from rev, newest
+// first, skipping merges. With paths, only commits touching them count.
+func RecentMessages(rev string, n int, paths []string) ([]string, error) {
+	args := []string{"log", "--no-merges", fmt.Sprintf("-n%d", n), "--format=%B%x00", rev, "--"}
+	out, err := runGit(append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	var messages []string
+	for _, message := range strings.Split(out, "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// UnpublishedCount returns how many commits in base..head are not reachable
+// from any remote-tracking branch.
+func UnpublishedCount(base, head string) (int, error) {
+	out, err := runGit("rev-list", "--count", base+".."+head, "--not", "--remotes")
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimSpace(out), "%d", &n); err != nil {
+		return 0, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	return n, nil
+}
+
+// RewriteMessages recreates the commits (oldest first) with the replacement
+// messages in messages, keyed by original hash, and moves HEAD to the new
+// tip. Trees, authors and author dates are preserved, so the work tree and
+// index are untouched; commits are only recreated from the first changed one
+// onward. The previous tip is saved as ORIG_HEAD.
+func RewriteMessages(commits []CommitInfo, messages map[string]string) (string, error) {
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits to rewrite")
+	}
+	oldTip := commits[len(commits)-1].Hash
+	head, err := ResolveCommit("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if head != oldTip {
+		return "", fmt.Errorf("HEAD moved during reword; nothing was changed")
+	}
+
+	rewritten := make(map[string]string, len(commits))
+	for _, commit := range commits {
+		parents := make([]string, len(commit.Parents))
+		parentsChanged := false
+		for i, parent := range commit.Parents {
+			parents[i] = parent
+			if newParent, ok := rewritten[parent]; ok && newParent != parent {
+				parents[i] = newParent
+				parentsChanged = true
+			}
+		}
+		message, reworded := messages[commit.Hash]
+		if !reworded && !parentsChanged {
+			rewritten[commit.Hash] = commit.Hash
+			continue
+		}
+		if !reworded {
+			message = commit.Message
+		}
+		newHash, err := commitTree(commit.Hash, parents, message)
+		if err != nil {
+			return "", fmt.Errorf("failed to rewrite %s: %w", commit.Hash[:7], err)
+		}
+		diag.Debug("git", "rewrote commit", "old", commit.Hash, "new", newHash, "reworded", reworded)
+		rewritten[commit.Hash] = newHash
+	}
+
+	newTip := rewritten[oldTip]
+	if newTip == oldTip {
+		return oldTip, nil
+	}
+	if _, err := runGit("update-ref", "-m", "gitcomm reword", "HEAD", newTip, oldTip); err != nil {
+		return "", err
+	}
+	if _, err := runGit("update-ref", "ORIG_HEAD", oldTip); err != nil {
+		diag.Warn("git", "failed to record ORIG_HEAD after reword", "error", err)
+	}
+	diag.Info("git", "reworded commits", "old_tip", oldTip, "new_tip", newTip, "reworded", len(messages))
+	return newTip, nil
+}
+
+func commitTree(original string, parents []string, message string) (string, error) {
+	ident, err := runGit("log", "-1", "--format=%an%x00%ae%x00%aD", original)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Split(strings.TrimRight(ident, "\n"), "\x00")
+	if len(fields) != 3 {
+		return "", fmt.Errorf("unexpected author format for %s", original)
+	}
+
+	args := []string{"commit-tree", original + "^{tree}"}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	cmd := current.command(args...)
+	cmd.Env = append(cmd.Env,
+		"GIT_AUTHOR_NAME="+fields[0],
+		"GIT_AUTHOR_EMAIL="+fields[1],
+		"GIT_AUTHOR_DATE="+fields[2],
+	)
+	cmd.Stdin = strings.NewReader(message + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git commit-tree: %s", firstErrorLine(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,140 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+)
+
+// Scope selects the changes to analyze and commit when they are not simply
+// the staged ones. Each non-empty scope is built in a temporary index, so
+// the commit contains exactly what was analyzed and the real index is only
+// updated once the commit succeeds.
+type Scope struct {
+	// Paths takes these pathspecs from the work tree, on top of HEAD, like
+	// `git commit -- <paths>`. Other staged changes are left out.
+	Paths []string
+	// All adds modified and deleted tracked files to the staged changes,
+	// like `git commit -a`.
+	All bool
+	// WorkTree compares the work tree, including untracked files, with the
+	// index. It is for previews and is never committed.
+	WorkTree bool
+}
+
+// Empty reports whether the scope is the plain staged changes.
+func (s Scope) Empty() bool {
+	return len(s.Paths) == 0 && !s.All && !s.WorkTree
+}
+
+// ScopedIndex is a temporary index holding the changes a Scope selects.
+type ScopedIndex struct {
+	// Repo runs git against the temporary index.
+	Repo *Repo
+	// Base is the tree the changes are compared against for a WorkTree
+	// scope, and "" otherwise.
+	Base   string
+	scope  Scope
+	parent *Repo
+	path   string
+}
+
+// withEnv returns a copy of r that adds env to every command.
+func (r *Repo) withEnv(env ...string) *Repo {
+	copied := *r
+	copied.Env = append(append([]string(nil), r.Env...), env...)
+	return &copied
+}
+
+// PrepareScope builds the temporary index for scope. Close must be called
+// when it is no longer needed.
+func (r *Repo) PrepareScope(scope Scope) (*ScopedIndex, error) {
+	if len(scope.Paths) > 0 && (scope.All || scope.WorkTree) {
+		return nil, fmt.Errorf("paths cannot be combined with -a or -wt")
+	}
+	index, err := r.GitPath("index")
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(filepath.Dir(index), "gitcomm-index-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a temporary index: %w", err)
+	}
+	file.Close()
+	// git refuses an empty index file; it creates the file when it writes.
+	os.Remove(file.Name())
+	s := &ScopedIndex{
+		Repo:   r.withEnv("GIT_INDEX_FILE=" + file.Name()),
+		scope:  scope,
+		parent: r,
+		path:   file.Name(),
+	}
+	if err := s.fill(index); err != nil {
+		s.Close()
+		return nil, err
+	}
+	diag.Info("git", "prepared scoped index", "paths", strings.Join(scope.Paths, " "), "all", scope.All, "worktree", scope.WorkTree, "base", s.Base)
+	return s, nil
+}
+
+func (s *ScopedIndex) fill(index string) error {
+	if len(s.scope.Paths) > 0 {
+		readTree := []string{"read-tree", "--empty"}
+		if s.parent.HasHead() {
+			readTree = []string{"read-tree", "HEAD"}
+		}
+		if _, err := s.Repo.run(readTree...); err != nil {
+			return err
+		}
+		_, err := s.Repo.run(append([]string{"add", "-A", "--"}, s.scope.Paths...)...)
+		return err
+	}
+
+	// -a and -wt start from the real index. An index that does not exist
+	// yet is an empty one.
+	data, err := os.ReadFile(index)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the index: %w", err)
+	}
+	if len(data) > 0 {
+		if err := os.WriteFile(s.path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to copy the index: %w", err)
+		}
+	}
+	if s.scope.WorkTree {
+		tree, err := s.parent.run("write-tree")
+		if err != nil {
+			return err
+		}
+		s.Base = strings.TrimSpace(tree)
+		_, err = s.Repo.run("add", "-A", ":/")
+		return err
+	}
+	_, err = s.Repo.run("add", "-u", ":/")
+	return err
+}
+
+// Finish brings the real index in line with the commit made from the
+// temporary one, as `git commit -a` and `git commit -- <paths>` do.
+func (s *ScopedIndex) Finish() error {
+	switch {
+	case s.scope.WorkTree:
+		return nil
+	case len(s.scope.Paths) > 0:
+		_, err := s.parent.run(append([]string{"reset", "-q", "HEAD", "--"}, s.scope.Paths...)...)
+		return err
+	}
+	// Everything that was staged went into the commit.
+	_, err := s.parent.run("reset", "-q", "HEAD")
+	return err
+}
+
+// Close removes the temporary index.
+func (s *ScopedIndex) Close() {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		diag.Warn("git", "failed to remove temporary index", "path", s.path, "error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+// scopeFixture commits a.txt and b.txt, then leaves a staged change to a.txt,
+// an unstaged change to b.txt and an untracked c.txt.
+func scopeFixture(t *testing.T) {
+	t.Helper()
+	newTestRepo(t)
+	writeFile(t, "a.txt", "a\n")
+	writeFile(t, "b.txt", "b\n")
+	gitRun(t, "add", ".")
+	gitRun(t, "commit", "-qm", "first")
+	writeFile(t, "a.txt", "a staged\n")
+	gitRun(t, "add", "a.txt")
+	writeFile(t, "b.txt", "b unstaged\n")
+	writeFile(t, "c.txt", "c\n")
+}
+
+func scopedPaths(t *testing.T, scope Scope) (*ScopedIndex, []string) {
+	t.Helper()
+	scoped, err := Default().PrepareScope(scope)
+	if err != nil {
+		t.Fatalf("PrepareScope(%+v) error = %v", scope, err)
+	}
+	t.Cleanup(scoped.Close)
+	changes, err := scoped.Repo.GetStagedChanges(DiffOptions{Base: scoped.Base})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	var paths []string
+	for _, file := range changes.Files {
+		paths = append(paths, file.Path)
+	}
+	return scoped, paths
+}
+
+func TestScopePathsCommitsOnlyThosePaths(t *testing.T) {
+	scopeFixture(t)
+	scoped, paths := scopedPaths(t, Scope{Paths: []string{"b.txt", "c.txt"}})
+	if strings.Join(paths, " ") != "b.txt c.txt" {
+		t.Fatalf("expected only the given paths, got %v", paths)
+	}
+	// Changes after the analysis must not leak into the commit.
+	writeFile(t, "b.txt", "edited later\n")
+
+	if err := scoped.Repo.Commit("Change b, add c", CommitOptions{Extra: []string{"-q"}}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := scoped.Finish(); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if got := gitRun(t, "show", "HEAD:b.txt"); got != "b unstaged" {
+		t.Fatalf("expected the analyzed content committed, got %q", got)
+	}
+	if got := gitRun(t, "diff", "--cached", "--name-only"); got != "a.txt" {
+		t.Fatalf("the staged a.txt should stay staged and uncommitted, got %q", got)
+	}
+	if got := gitRun(t, "status", "--porcelain", "c.txt"); got != "" {
+		t.Fatalf("c.txt should be committed and tracked, got %q", got)
+	}
+}
+
+func TestScopeAllAddsTrackedChanges(t *testing.T) {
+	scopeFixture(t)
+	scoped, paths := scopedPaths(t, Scope{All: true})
+	if strings.Join(paths, " ") != "a.txt b.txt" {
+		t.Fatalf("expected staged and tracked changes without untracked files, got %v", paths)
+	}
+	if got := gitRun(t, "diff", "--cached", "--name-only"); got != "a.txt" {
+		t.Fatalf("the real index must not change before committing, got %q", got)
+	}
+	if err := scoped.Repo.Commit("Change a and b", CommitOptions{Extra: []string{"-q"}}); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := scoped.Finish(); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if got := gitRun(t, "status", "--porcelain"); got != "?? c.txt" {
+		t.Fatalf("expected only c.txt left over, got %q", got)
+	}
+}
+
+func TestScopeWorkTreeComparesWithIndex(t *testing.T) {
+	scopeFixture(t)
+	_, paths := scopedPaths(t, Scope{WorkTree: true})
+	if strings.Join(paths, " ") != "b.txt c.txt" {
+		t.Fatalf("expected the unstaged and untracked changes, got %v", paths)
+	}
+	if got := gitRun(t, "status", "--porcelain"); got != "M  a.txt\n M b.txt\n?? c.txt" {
+		t.Fatalf("the index must be untouched, got %q", got)
+	}
+}
+
+func TestScopeInRepositoryWithoutCommits(t *testing.T) {
+	newTestRepo(t)
+	writeFile(t, "a.txt", "a\n")
+	writeFile(t, "b.txt", "b\n")
+	if _, paths := scopedPaths(t, Scope{Paths: []string{"a.txt"}}); strings.Join(paths, " ") != "a.txt" {
+		t.Fatalf("expected a.txt, got %v", paths)
+	}
+	if _, paths := scopedPaths(t, Scope{WorkTree: true}); strings.Join(paths, " ") != "a.txt b.txt" {
+		t.Fatalf("expected both files, got %v", paths)
+	}
+	if _, err := Default().PrepareScope(Scope{Paths: []string{"missing.txt"}}); err == nil {
+		t.Fatal("expected an error for a pathspec that matches nothing")
+	}
+}
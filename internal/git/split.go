@@ -0,0 +1,308 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/ignore"
+)
+
+// SplitIndexRef keeps the original index tree reachable while a split is in
+// progress so it survives gc and can be restored after an interruption;
+// SplitHeadRef records the commit the split started from.
+const (
+	SplitIndexRef = "refs/gitcomm/split-index"
+	SplitHeadRef  = "refs/gitcomm/split-head"
+)
+
+// SplitUnit is the smallest piece of the staged diff split can assign to a
+// commit: a single hunk of a modified file, or a whole file when its change
+// cannot be divided (new, deleted, binary, or mode changes).
+type SplitUnit struct {
+	ID   int
+	File *gitdiff.File
+	// Hunk indexes File.Hunks, or is -1 when the unit is the whole file.
+	Hunk int
+	// Omitted describes the unit instead of its content when the file is
+	// excluded by ignore patterns, or is binary, generated or vendored.
+	Omitted string
+}
+
+func (u SplitUnit) Path() string {
+	return u.File.Path()
+}
+
+// Diff renders the unit's own lines (without file headers) for the model,
+// or a one-line placeholder when its content is omitted.
+func (u SplitUnit) Diff() string {
+	if u.Omitted != "" {
+		return "[[gitcomm: " + u.Omitted + "]]"
+	}
+	if u.Hunk < 0 {
+		return strings.Join(u.File.Lines(), "\n")
+	}
+	hunk := u.File.Hunks[u.Hunk]
+	lines := []string{hunk.Header}
+	for _, line := range hunk.Lines {
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StagedUnits reads the full staged diff, untruncated and without rename
+// detection so every piece can be applied on its own, and numbers its units
+// from 1. The diff includes binary data for `git apply`; units of files that
+// exclude matches, or that are collapsed in the main diff, are only
+// described to the model.
+func StagedUnits(exclude *ignore.Matcher) ([]SplitUnit, error) {
+	args := []string{"diff", "--cached", "--binary", "--no-renames", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/"}
+	if HasHead() {
+		args = append(args, "HEAD")
+	}
+	out, err := runGit(append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := gitdiff.Parse(out)
+	omitted := current.omittedFiles(patch, exclude)
+	var units []SplitUnit
+	for _, file := range patch.Files {
+		reason := omitted[file]
+		if !splittable(file) {
+			unit := SplitUnit{ID: len(units) + 1, File: file, Hunk: -1}
+			if reason == excludedReason {
+				unit.Omitted = reason
+			} else if reason != "" {
+				unit.Omitted = describeCollapsed(file, reason)
+			}
+			units = append(units, unit)
+			continue
+		}
+		for i := range file.Hunks {
+			unit := SplitUnit{ID: len(units) + 1, File: file, Hunk: i}
+			if reason != "" {
+				unit.Omitted = reason + "; hunk omitted"
+			}
+			units = append(units, unit)
+		}
+	}
+	return units, nil
+}
+
+// omittedFiles returns why each file in patch should not be shown to the
+// model: matched by exclude, or collapsed for the same reasons as in
+// GetStagedChanges. Unlike collapseDetectedFiles it leaves patch intact, so
+// it can still be applied.
+func (r *Repo) omittedFiles(patch *gitdiff.Patch, exclude *ignore.Matcher) map[*gitdiff.File]string {
+	paths := filePaths(patch)
+	attrs, err := r.checkAttrs(paths)
+	if err != nil {
+		diag.Warn("git", "failed to read gitattributes for split", "error", err)
+	}
+	heads, err := r.readBlobHeads("", paths, generatedHeaderLines)
+	if err != nil {
+		diag.Warn("git", "failed to read file headers for split", "error", err)
+	}
+	omitted := make(map[*gitdiff.File]string)
+	for _, file := range patch.Files {
+		if exclude.Match(file.Path()) {
+			omitted[file] = excludedReason
+		} else if reason := classifyFile(file, attrs[file.Path()], heads[file.Path()]); reason != "" {
+			omitted[file] = reason
+		}
+	}
+	return omitted
+}
+
+func splittable(file *gitdiff.File) bool {
+	return file.Status == gitdiff.StatusModified && !file.Binary && file.OldMode == file.NewMode && len(file.Hunks) > 1
+}
+
+// SubsetPatch renders a patch containing only the given units, in diff order,
+// suitable for `git apply --cached`.
+func SubsetPatch(units []SplitUnit, ids []int) string {
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	var lines []string
+	var current *gitdiff.File
+	for _, unit := range units {
+		if !selected[unit.ID] {
+			continue
+		}
+		if unit.Hunk < 0 {
+			lines = append(lines, unit.File.Lines()...)
+			current = nil
+			continue
+		}
+		if unit.File != current {
+			lines = append(lines, unit.File.Header...)
+			current = unit.File
+		}
+		hunk := unit.File.Hunks[unit.Hunk]
+		lines = append(lines, hunk.Header)
+		for _, line := range hunk.Lines {
+			lines = append(lines, line.String())
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// SplitBackup records the state a split starts from.
+type SplitBackup struct {
+	Head  string
+	Index string
+}
+
+// BackupIndex writes the current index as a tree and pins it, with HEAD,
+// under the split refs.
+func BackupIndex() (*SplitBackup, error) {
+	tree, err := runGit("write-tree")
+	if err != nil {
+		return nil, err
+	}
+	backup := &SplitBackup{Index: strings.TrimSpace(tree)}
+	if HasHead() {
+		if backup.Head, err = ResolveCommit("HEAD"); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := runGit("update-ref", SplitIndexRef, backup.Index); err != nil {
+		return nil, err
+	}
+	if backup.Head != "" {
+		if _, err := runGit("update-ref", SplitHeadRef, backup.Head); err != nil {
+			return nil, err
+		}
+	}
+	diag.Info("git", "backed up index for split", "tree", backup.Index, "head", backup.Head)
+	return backup, nil
+}
+
+// Restore moves HEAD back to where the split started and reloads the
+// original index. The work tree is never touched by split, so nothing else
+// needs undoing.
+func (b *SplitBackup) Restore() error {
+	if b.Head != "" {
+		if _, err := runGit("update-ref", "-m", "gitcomm split: restore", "HEAD", b.Head); err != nil {
+			return err
+		}
+	} else if current, _ := runGitQuiet("symbolic-ref", "--quiet", "HEAD"); strings.TrimSpace(current) != "" {
+		// Back to an unborn branch.
+		if _, err := runGitQuiet("update-ref", "-d", strings.TrimSpace(current)); err != nil {
+			diag.Warn("git", "failed to reset unborn branch after split", "ref", strings.TrimSpace(current), "error", err)
+		}
+	}
+	if _, err := runGit("read-tree", b.Index); err != nil {
+		return err
+	}
+	diag.Info("git", "restored index after split", "tree", b.Index, "head", b.Head)
+	return b.Discard()
+}
+
+// Discard drops the backup refs once the split has finished.
+func (b *SplitBackup) Discard() error {
+	if _, err := runGitQuiet("update-ref", "-d", SplitHeadRef); err != nil {
+		diag.Debug("git", "no split head ref to delete", "error", err)
+	}
+	_, err := runGit("update-ref", "-d", SplitIndexRef)
+	return err
+}
+
+// PendingSplit returns the backup left by an interrupted split, or nil.
+func PendingSplit() *SplitBackup {
+	index, err := runGitQuiet("rev-parse", "--verify", "--quiet", SplitIndexRef)
+	if err != nil {
+		return nil
+	}
+	backup := &SplitBackup{Index: strings.TrimSpace(index)}
+	if head, err := runGitQuiet("rev-parse", "--verify", "--quiet", SplitHeadRef); err == nil {
+		backup.Head = strings.TrimSpace(head)
+	}
+	return backup
+}
+
+// ResetIndexToHead empties the staging area back to HEAD without touching
+// the work tree.
+func ResetIndexToHead() error {
+	if HasHead() {
+		_, err := runGit("read-tree", "HEAD")
+		return err
+	}
+	_, err := runGit("read-tree", "--empty")
+	return err
+}
+
+// ApplyToIndex stages patch with `git apply --cached`.
+func ApplyToIndex(patch string) error {
+	return applyCached(patch, nil)
+}
+
+// CheckSplit applies the patches in order to a scratch index starting from
+// HEAD and verifies that the result matches the backed-up index, so a plan
+// that drops or duplicates changes is rejected before anything is committed.
+func CheckSplit(patches []string, want string) error {
+	dir, err := os.MkdirTemp("", "gitcomm-split-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")}
+
+	readTree := []string{"read-tree", "--empty"}
+	if HasHead() {
+		readTree = []string{"read-tree", "HEAD"}
+	}
+	if err := runGitEnv(env, "", readTree...); err != nil {
+		return err
+	}
+	for i, patch := range patches {
+		if err := applyCached(patch, env); err != nil {
+			return fmt.Errorf("commit %d does not apply: %w", i+1, err)
+		}
+	}
+	cmd := current.command("write-tree")
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git write-tree: %w", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != want {
+		return fmt.Errorf("the planned commits do not reproduce the staged changes")
+	}
+	return nil
+}
+
+func applyCached(patch string, env []string) error {
+	return runGitEnv(env, patch, "apply", "--cached", "--whitespace=nowarn", "-")
+}
+
+func runGitEnv(env []string, stdin string, args ...string) error {
+	return current.runEnv(env, stdin, args...)
+}
+
+// GitPath returns the absolute path of name inside the repository's git
+// directory.
+func GitPath(name string) (string, error) {
+	return current.GitPath(name)
+}
+
+// Editor returns the editor git itself would use (GIT_EDITOR, core.editor,
+// VISUAL, EDITOR, then vi).
+func Editor() (string, error) {
+	out, err := runGit("var", "GIT_EDITOR")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,159 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/ignore"
+)
+
+func stageSplitFixture(t *testing.T) string {
+	t.Helper()
+	newTestRepo(t)
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, "line")
+	}
+	writeFile(t, "a.txt", strings.Join(lines, "\n")+"\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "base")
+	head := gitRun(t, "rev-parse", "HEAD")
+
+	lines[1] = "top change"
+	lines[27] = "bottom change"
+	writeFile(t, "a.txt", strings.Join(lines, "\n")+"\n")
+	writeFile(t, "b.txt", "new\n")
+	gitRun(t, "add", "a.txt", "b.txt")
+	return head
+}
+
+func TestStagedUnitsAndSubsetPatchesReproduceIndex(t *testing.T) {
+	head := stageSplitFixture(t)
+	units, err := StagedUnits(nil)
+	if err != nil {
+		t.Fatalf("StagedUnits() error = %v", err)
+	}
+	if len(units) != 3 || units[0].Hunk != 0 || units[1].Hunk != 1 || units[2].Hunk != -1 || units[2].Path() != "b.txt" {
+		t.Fatalf("unexpected units: %+v", units)
+	}
+
+	backup, err := BackupIndex()
+	if err != nil {
+		t.Fatalf("BackupIndex() error = %v", err)
+	}
+	if pending := PendingSplit(); pending == nil || pending.Index != backup.Index || pending.Head != head {
+		t.Fatalf("PendingSplit() = %+v", pending)
+	}
+
+	patches := []string{SubsetPatch(units, []int{2}), SubsetPatch(units, []int{3, 1})}
+	if err := CheckSplit(patches, backup.Index); err != nil {
+		t.Fatalf("CheckSplit() error = %v", err)
+	}
+	if err := CheckSplit(patches[:1], backup.Index); err == nil {
+		t.Fatal("expected CheckSplit to reject a plan that drops changes")
+	}
+
+	if err := ResetIndexToHead(); err != nil {
+		t.Fatal(err)
+	}
+	for i, patch := range patches {
+		if err := ApplyToIndex(patch); err != nil {
+			t.Fatalf("ApplyToIndex(%d) error = %v", i, err)
+		}
+		if err := Default().Commit("part "+string(rune('1'+i)), CommitOptions{Quiet: true}); err != nil {
+			t.Fatalf("Commit(%d) error = %v", i, err)
+		}
+	}
+	if got := gitRun(t, "rev-parse", "HEAD^{tree}"); got != backup.Index {
+		t.Fatalf("final tree %s, want %s", got, backup.Index)
+	}
+	if got := gitRun(t, "show", "--format=", "--name-only", "HEAD~1"); got != "a.txt" {
+		t.Fatalf("first commit should only touch a.txt, got %q", got)
+	}
+	if err := backup.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if PendingSplit() != nil {
+		t.Fatal("expected backup refs to be removed")
+	}
+}
+
+func TestSplitBackupRestoreUndoesPartialSplit(t *testing.T) {
+	head := stageSplitFixture(t)
+	units, err := StagedUnits(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := BackupIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ResetIndexToHead(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyToIndex(SubsetPatch(units, []int{1})); err != nil {
+		t.Fatal(err)
+	}
+	if err := Default().Commit("partial", CommitOptions{Quiet: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PendingSplit().Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := gitRun(t, "rev-parse", "HEAD"); got != head {
+		t.Fatalf("HEAD = %s, want %s", got, head)
+	}
+	if got := gitRun(t, "write-tree"); got != backup.Index {
+		t.Fatalf("index tree = %s, want %s", got, backup.Index)
+	}
+	if PendingSplit() != nil {
+		t.Fatal("expected backup refs to be removed after restore")
+	}
+}
+
+func TestStagedUnitsHideBinaryGeneratedAndExcludedContent(t *testing.T) {
+	stageSplitFixture(t)
+	writeFile(t, "logo.png", "\x89PNG\x00\x01\x02binary")
+	writeFile(t, "api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n")
+	writeFile(t, "secret/keys.txt", "token=hunter2\n")
+	gitRun(t, "add", ".")
+
+	units, err := StagedUnits(ignore.New([]string{"secret/"}))
+	if err != nil {
+		t.Fatalf("StagedUnits() error = %v", err)
+	}
+	omitted := map[string]string{}
+	for _, unit := range units {
+		diff := unit.Diff()
+		if strings.Contains(diff, "GIT binary patch") || strings.Contains(diff, "hunter2") || strings.Contains(diff, "package api") {
+			t.Fatalf("unit %d leaks content to the prompt:\n%s", unit.ID, diff)
+		}
+		omitted[unit.Path()] = diff
+	}
+	for path, want := range map[string]string{
+		"logo.png":        "[[gitcomm: binary file; content omitted]]",
+		"api.pb.go":       "[[gitcomm: generated file (Code generated header); 3 lines added, 0 removed; hunks omitted]]",
+		"secret/keys.txt": "[[gitcomm: excluded by ignore patterns]]",
+	} {
+		if omitted[path] != want {
+			t.Fatalf("%s: Diff() = %q, want %q", path, omitted[path], want)
+		}
+	}
+	if !strings.Contains(omitted["b.txt"], "+new") {
+		t.Fatalf("ordinary units should keep their content, got %q", omitted["b.txt"])
+	}
+
+	// The binary data is still there for git apply.
+	backup, err := BackupIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(units))
+	for i, unit := range units {
+		ids[i] = unit.ID
+	}
+	if err := CheckSplit([]string{SubsetPatch(units, ids)}, backup.Index); err != nil {
+		t.Fatalf("CheckSplit() error = %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"os"
+	"strings"
+)
+
+// Operation kinds reported by InProgress.
+const (
+	OpMerge      = "merge"
+	OpRebase     = "rebase"
+	OpCherryPick = "cherry-pick"
+	OpRevert     = "revert"
+)
+
+// Operation describes an unfinished merge, rebase, cherry-pick or revert.
+type Operation struct {
+	Kind string
+	// Message is the message git prepared (MERGE_MSG, or the message of the
+	// commit a rebase is replaying), without comment lines.
+	Message string
+	// Conflicts lists the paths git reported as conflicting, resolved or not.
+	Conflicts []string
+}
+
+// InProgress inspects the git directory and returns the operation waiting
+// to be finished, or nil when there is none. A stopped rebase wins over the
+// cherry-pick state it uses internally.
+func InProgress() *Operation {
+	switch {
+	case RebaseInProgress():
+		op := &Operation{Kind: OpRebase}
+		for _, name := range []string{"rebase-merge/message", "rebase-apply/msg", "MERGE_MSG"} {
+			if raw := readGitFile(name); raw != "" {
+				op.Message, op.Conflicts = parsePreparedMessage(raw, CommentChar())
+				break
+			}
+		}
+		return op
+	case gitFileExists("MERGE_HEAD"):
+		return preparedOperation(OpMerge)
+	case gitFileExists("CHERRY_PICK_HEAD"):
+		return preparedOperation(OpCherryPick)
+	case gitFileExists("REVERT_HEAD"):
+		return preparedOperation(OpRevert)
+	}
+	return nil
+}
+
+func preparedOperation(kind string) *Operation {
+	op := &Operation{Kind: kind}
+	op.Message, op.Conflicts = parsePreparedMessage(readGitFile("MERGE_MSG"), CommentChar())
+	return op
+}
+
+// RebaseInProgress reports whether a rebase has stopped and is waiting for
+// the user.
+func RebaseInProgress() bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := GitPath(name)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmergedPaths lists paths that still have conflict stages in the index.
+func UnmergedPaths() ([]string, error) {
+	out, err := runGit("diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNULPaths(out), nil
+}
+
+// parsePreparedMessage splits a message git prepared into its text and the
+// paths listed under its "Conflicts:" comment.
+func parsePreparedMessage(raw, commentChar string) (string, []string) {
+	var lines, conflicts []string
+	inConflicts := false
+	for _, line := range strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, commentChar) {
+			inConflicts = false
+			lines = append(lines, line)
+			continue
+		}
+		comment := strings.TrimPrefix(line, commentChar)
+		switch {
+		case strings.TrimSpace(comment) == "Conflicts:":
+			inConflicts = true
+		case inConflicts && strings.HasPrefix(comment, "\t"):
+			conflicts = append(conflicts, strings.TrimSpace(comment))
+		case strings.TrimSpace(comment) == "":
+		default:
+			inConflicts = false
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), conflicts
+}
+
+func readGitFile(name string) string {
+	path, err := GitPath(name)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func gitFileExists(name string) bool {
+	path, err := GitPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
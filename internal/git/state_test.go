@@ -0,0 +1,81 @@
+package git
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// conflictFixture creates main and feature branches that both change a.txt.
+func conflictFixture(t *testing.T) {
+	t.Helper()
+	newTestRepo(t)
+	writeFile(t, "a.txt", "one\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	gitRun(t, "checkout", "-qb", "feature")
+	writeFile(t, "a.txt", "feature\n")
+	gitRun(t, "commit", "-qam", "Change a on feature")
+	gitRun(t, "checkout", "-q", "main")
+	writeFile(t, "a.txt", "main\n")
+	gitRun(t, "commit", "-qam", "Change a on main")
+}
+
+// gitFail runs a git command that is expected to stop with conflicts.
+func gitFail(t *testing.T, args ...string) {
+	t.Helper()
+	if err := exec.Command("git", args...).Run(); err == nil {
+		t.Fatalf("git %s: expected a conflict", strings.Join(args, " "))
+	}
+}
+
+func TestInProgressMergeReportsMessageAndConflicts(t *testing.T) {
+	conflictFixture(t)
+	if op := InProgress(); op != nil {
+		t.Fatalf("expected no operation, got %+v", op)
+	}
+	gitFail(t, "merge", "feature")
+
+	op := InProgress()
+	if op == nil || op.Kind != OpMerge || op.Message != "Merge branch 'feature'" || !reflect.DeepEqual(op.Conflicts, []string{"a.txt"}) {
+		t.Fatalf("unexpected operation: %+v", op)
+	}
+	if unmerged, err := UnmergedPaths(); err != nil || !reflect.DeepEqual(unmerged, []string{"a.txt"}) {
+		t.Fatalf("UnmergedPaths() = %v, %v", unmerged, err)
+	}
+	writeFile(t, "a.txt", "both\n")
+	gitRun(t, "add", "a.txt")
+	if unmerged, _ := UnmergedPaths(); len(unmerged) != 0 {
+		t.Fatalf("expected conflicts to be resolved, got %v", unmerged)
+	}
+}
+
+func TestInProgressCherryPickRevertAndRebase(t *testing.T) {
+	conflictFixture(t)
+	gitFail(t, "cherry-pick", "-x", "feature")
+	op := InProgress()
+	if op == nil || op.Kind != OpCherryPick || !strings.Contains(op.Message, "(cherry picked from commit ") {
+		t.Fatalf("unexpected cherry-pick operation: %+v", op)
+	}
+	gitRun(t, "cherry-pick", "--abort")
+
+	writeFile(t, "a.txt", "later\n")
+	gitRun(t, "commit", "-qam", "Change a again")
+	gitFail(t, "revert", "--no-edit", "HEAD~1")
+	op = InProgress()
+	if op == nil || op.Kind != OpRevert || !strings.Contains(op.Message, "This reverts commit ") {
+		t.Fatalf("unexpected revert operation: %+v", op)
+	}
+	gitRun(t, "revert", "--abort")
+
+	gitRun(t, "checkout", "-q", "feature")
+	gitFail(t, "rebase", "main")
+	op = InProgress()
+	if op == nil || op.Kind != OpRebase || !strings.HasPrefix(op.Message, "Change a on feature") {
+		t.Fatalf("unexpected rebase operation: %+v", op)
+	}
+	if !RebaseInProgress() {
+		t.Fatal("RebaseInProgress() = false")
+	}
+}
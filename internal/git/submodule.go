@@ -0,0 +1,78 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/ignore"
+)
+
+// maxSubmoduleLog caps the commits listed for one submodule bump.
+const maxSubmoduleLog = 20
+
+// describeSubmodules lists the submodule changes in patch that are not
+// excluded, with the commits each bump brings in when the submodule is
+// checked out.
+func (r *Repo) describeSubmodules(patch *gitdiff.Patch, exclude *ignore.Matcher) []gitdiff.Submodule {
+	all := patch.Submodules()
+	if len(all) == 0 {
+		return nil
+	}
+	root, err := r.Root()
+	if err != nil {
+		diag.Warn("git", "failed to find repository root for submodules", "error", err)
+	}
+	var submodules []gitdiff.Submodule
+	for _, sub := range all {
+		if exclude.Match(sub.Path) {
+			continue
+		}
+		if root != "" && sub.Old != "" && sub.New != "" {
+			sub.Log, sub.LogOmitted = r.submoduleLog(filepath.Join(root, filepath.FromSlash(sub.Path)), sub.Old, sub.New)
+		}
+		submodules = append(submodules, sub)
+	}
+	diag.Info("git", "described submodule changes", "count", len(submodules))
+	return submodules
+}
+
+// submoduleLog runs `git log --oneline old..new` inside a checked-out
+// submodule. It returns nothing when the submodule is not checked out or
+// does not have both commits.
+func (r *Repo) submoduleLog(dir, old, new string) ([]string, int) {
+	// Without its own .git, `git -C dir` would find the superproject.
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil, 0
+	}
+	cmd := exec.Command(r.binary(), "-C", dir, "log", "--oneline", "--no-decorate", "--no-color", old+".."+new)
+	cmd.Env = submoduleEnv(append(os.Environ(), r.Env...))
+	out, err := cmd.Output()
+	if err != nil {
+		diag.Debug("git", "submodule log unavailable", "dir", dir, "error", err)
+		return nil, 0
+	}
+	lines := splitDiffLines(string(out))
+	if len(lines) > maxSubmoduleLog {
+		return lines[:maxSubmoduleLog], len(lines) - maxSubmoduleLog
+	}
+	return lines, 0
+}
+
+// submoduleEnv drops the variables that point git at the superproject, such
+// as those git sets while running a hook.
+func submoduleEnv(env []string) []string {
+	kept := env[:0:0]
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		switch name {
+		case "GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_COMMON_DIR":
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
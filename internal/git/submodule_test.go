@@ -0,0 +1,64 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStagedChangesDescribeSubmoduleBump(t *testing.T) {
+	dir := newTestRepo(t)
+	// The submodule repositories do not share the test repo's identity.
+	for _, key := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(key+"_NAME", "Test")
+		t.Setenv(key+"_EMAIL", "test@example.com")
+	}
+	lib := filepath.Join(dir, "lib-upstream")
+	gitRun(t, "init", "-q", "-b", "main", lib)
+	writeFile(t, filepath.Join(lib, "lib.go"), "package lib\n")
+	gitRun(t, "-C", lib, "add", ".")
+	gitRun(t, "-C", lib, "commit", "-qm", "Initial lib")
+
+	writeFile(t, "README.md", "app\n")
+	gitRun(t, "add", "README.md")
+	gitRun(t, "-c", "protocol.file.allow=always", "submodule", "add", "-q", lib, "lib")
+	gitRun(t, "commit", "-qm", "Add lib")
+	old := gitRun(t, "-C", "lib", "rev-parse", "HEAD")
+
+	for _, subject := range []string{"Add parser", "Fix lexer"} {
+		writeFile(t, filepath.Join("lib", strings.ReplaceAll(subject, " ", "_")+".go"), "package lib\n")
+		gitRun(t, "-C", "lib", "add", ".")
+		gitRun(t, "-C", "lib", "commit", "-qm", subject)
+	}
+	newID := gitRun(t, "-C", "lib", "rev-parse", "HEAD")
+	gitRun(t, "add", "lib")
+
+	changes, err := Default().GetStagedChanges(DiffOptions{})
+	if err != nil {
+		t.Fatalf("GetStagedChanges() error = %v", err)
+	}
+	if len(changes.Submodules) != 1 {
+		t.Fatalf("expected one submodule change, got %+v", changes.Submodules)
+	}
+	sub := changes.Submodules[0]
+	if sub.Path != "lib" || sub.Old != old || sub.New != newID {
+		t.Fatalf("unexpected submodule change %+v", sub)
+	}
+	if len(sub.Log) != 2 || !strings.HasSuffix(sub.Log[0], "Fix lexer") || !strings.HasSuffix(sub.Log[1], "Add parser") {
+		t.Fatalf("expected the log of the bump, got %q", sub.Log)
+	}
+}
+
+func TestSubmoduleLogNeedsCheckout(t *testing.T) {
+	dir := newTestRepo(t)
+	writeFile(t, "a.txt", "a\n")
+	gitRun(t, "add", "a.txt")
+	gitRun(t, "commit", "-qm", "first")
+	head := gitRun(t, "rev-parse", "HEAD")
+
+	// An empty directory must not fall through to the superproject's log.
+	writeFile(t, filepath.Join(dir, "lib", ".keep"), "")
+	if log, omitted := Default().submoduleLog(filepath.Join(dir, "lib"), head+"^", head); log != nil || omitted != 0 {
+		t.Fatalf("expected no log without a checkout, got %q (%d omitted)", log, omitted)
+	}
+}
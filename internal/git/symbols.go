@@ -0,0 +1,77 @@
+package git
+
+import (
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/symbols"
+)
+
+const (
+	// maxSymbolFiles and maxSymbolFileSize bound the work spent on symbols
+	// for very large changes; such files are rarely hand-written anyway.
+	maxSymbolFiles    = 100
+	maxSymbolFileSize = 1 << 20
+)
+
+// baseRev is the revision the diff's old side is read from.
+func (o DiffOptions) baseRev() string {
+	if o.Base != "" {
+		return o.Base
+	}
+	return "HEAD"
+}
+
+// changedSymbols compares the declarations in the old and new versions of
+// each changed source file. Files left out of the patch are skipped.
+func (r *Repo) changedSymbols(files []gitdiff.FileStat, opts DiffOptions) []symbols.Change {
+	var oldPaths, newPaths []string
+	var selected []gitdiff.FileStat
+	for _, file := range files {
+		if file.Omitted != "" || file.Binary || !symbols.Supported(file.Path) {
+			continue
+		}
+		if len(selected) == maxSymbolFiles {
+			diag.Info("git", "too many files for symbol extraction", "limit", maxSymbolFiles)
+			break
+		}
+		selected = append(selected, file)
+		if file.Status != gitdiff.StatusAdded {
+			oldPaths = append(oldPaths, oldPath(file))
+		}
+		if file.Status != gitdiff.StatusDeleted {
+			newPaths = append(newPaths, file.Path)
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	before, err := r.readBlobs(opts.baseRev(), oldPaths, maxSymbolFileSize+1)
+	if err != nil {
+		diag.Warn("git", "failed to read old file versions for symbols", "rev", opts.baseRev(), "error", err)
+		return nil
+	}
+	after, err := r.readBlobs(opts.blobRev(), newPaths, maxSymbolFileSize+1)
+	if err != nil {
+		diag.Warn("git", "failed to read new file versions for symbols", "rev", opts.blobRev(), "error", err)
+		return nil
+	}
+	var changes []symbols.Change
+	for _, file := range selected {
+		old, new := before[oldPath(file)], after[file.Path]
+		// readBlobs cut anything past the limit, so a longer blob is too big.
+		if len(old) > maxSymbolFileSize || len(new) > maxSymbolFileSize {
+			continue
+		}
+		changes = append(changes, symbols.Diff(file.Path, old, new)...)
+	}
+	diag.Info("git", "extracted changed symbols", "files", len(selected), "symbols", len(changes))
+	return changes
+}
+
+func oldPath(file gitdiff.FileStat) string {
+	if file.OldPath != "" {
+		return file.OldPath
+	}
+	return file.Path
+}
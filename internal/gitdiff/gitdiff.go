@@ -0,0 +1,440 @@
+// Package gitdiff parses unified diff text, as produced by `git diff`, into
+// typed File and Hunk structures that can be rendered back byte for byte.
+package gitdiff
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Status mirrors the letters used by `git diff --name-status`.
+type Status string
+
+const (
+	StatusModified    Status = "M"
+	StatusAdded       Status = "A"
+	StatusDeleted     Status = "D"
+	StatusRenamed     Status = "R"
+	StatusCopied      Status = "C"
+	StatusTypeChanged Status = "T"
+)
+
+type LineKind byte
+
+const (
+	LineContext   LineKind = ' '
+	LineAdded     LineKind = '+'
+	LineRemoved   LineKind = '-'
+	LineNoNewline LineKind = '\\'
+)
+
+type Line struct {
+	Kind LineKind
+	// Text is the line content without its one-character prefix.
+	Text string
+}
+
+func (l Line) String() string {
+	return string(l.Kind) + l.Text
+}
+
+type Hunk struct {
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the function context git prints after the closing @@.
+	Section string
+	Lines   []Line
+}
+
+type File struct {
+	OldPath    string
+	NewPath    string
+	Status     Status
+	OldMode    string
+	NewMode    string
+	Similarity int
+	Binary     bool
+	// Header holds the raw lines from "diff --git" (or "---") up to the first
+	// hunk, including extended headers such as "index" and "rename from".
+	Header []string
+	// BinaryPatch holds the encoded data that follows "GIT binary patch".
+	BinaryPatch []string
+	Hunks       []*Hunk
+	// Trailer holds lines after the last hunk that are not part of any hunk,
+	// such as editorial truncation markers.
+	Trailer []string
+	Added   int
+	Removed int
+	// Truncated is set on files that Truncate cut partway through.
+	Truncated bool
+}
+
+// Path returns the path the file has after the change, or its old path when
+// the file was deleted.
+func (f *File) Path() string {
+	if f.Status == StatusDeleted || f.NewPath == "" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// DisplayPath renders renames and copies as "old => new".
+func (f *File) DisplayPath() string {
+	if (f.Status == StatusRenamed || f.Status == StatusCopied) && f.OldPath != "" && f.OldPath != f.NewPath {
+		return f.OldPath + " => " + f.NewPath
+	}
+	return f.Path()
+}
+
+// Collapse replaces everything after the "diff --git" line with a single
+// editorial description, keeping Added and Removed so summaries stay accurate.
+func (f *File) Collapse(description string) {
+	header := []string{}
+	if len(f.Header) > 0 {
+		header = append(header, f.Header[0])
+	}
+	f.Header = append(header, "[[gitcomm: "+description+"]]")
+	f.BinaryPatch = nil
+	f.Hunks = nil
+	f.Trailer = nil
+}
+
+func (f *File) Lines() []string {
+	lines := make([]string, 0, len(f.Header)+len(f.BinaryPatch)+len(f.Trailer))
+	lines = append(lines, f.Header...)
+	lines = append(lines, f.BinaryPatch...)
+	for _, hunk := range f.Hunks {
+		lines = append(lines, hunk.Header)
+		for _, line := range hunk.Lines {
+			lines = append(lines, line.String())
+		}
+	}
+	return append(lines, f.Trailer...)
+}
+
+type Patch struct {
+	// Preamble holds lines before the first file, such as format-patch mail
+	// headers or notes printed by git.
+	Preamble []string
+	Files    []*File
+
+	trailingNewline bool
+}
+
+func (p *Patch) Lines() []string {
+	lines := append([]string(nil), p.Preamble...)
+	for _, file := range p.Files {
+		lines = append(lines, file.Lines()...)
+	}
+	return lines
+}
+
+func (p *Patch) LineCount() int {
+	return len(p.Lines())
+}
+
+func (p *Patch) String() string {
+	lines := p.Lines()
+	if len(lines) == 0 {
+		return ""
+	}
+	out := strings.Join(lines, "\n")
+	if p.trailingNewline {
+		out += "\n"
+	}
+	return out
+}
+
+// Stats returns the total number of added and removed lines across all files.
+func (p *Patch) Stats() (added, removed int) {
+	for _, file := range p.Files {
+		added += file.Added
+		removed += file.Removed
+	}
+	return added, removed
+}
+
+var hunkHeaderExpr = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// Parse never fails: lines it does not recognise are kept verbatim in the
+// Preamble, a file Header or a file Trailer so String reproduces the input.
+func Parse(diff string) *Patch {
+	patch := &Patch{trailingNewline: strings.HasSuffix(diff, "\n")}
+	lines := splitLines(diff)
+
+	var (
+		file   *File
+		hunk   *Hunk
+		oldRem int
+		newRem int
+		counts bool
+	)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if hunk != nil {
+			if consumeHunkLine(hunk, file, line, counts, &oldRem, &newRem) {
+				continue
+			}
+			hunk = nil
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = newGitFile(line)
+			patch.Files = append(patch.Files, file)
+			continue
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") && (file == nil || len(file.Hunks) > 0 || !isGitHeader(file)):
+			// Plain unified diffs (diff -u, svn, some review tools) have no
+			// "diff --git" line; a ---/+++ pair starts the next file.
+			file = &File{Status: StatusModified}
+			patch.Files = append(patch.Files, file)
+		}
+
+		if file == nil {
+			patch.Preamble = append(patch.Preamble, line)
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			hunk = parseHunkHeader(line)
+			counts = hunk.OldLines >= 0
+			oldRem, newRem = hunk.OldLines, hunk.NewLines
+			file.Hunks = append(file.Hunks, hunk)
+			continue
+		}
+		if len(file.Hunks) > 0 {
+			file.Trailer = append(file.Trailer, line)
+			continue
+		}
+		if len(file.BinaryPatch) > 0 {
+			file.BinaryPatch = append(file.BinaryPatch, line)
+			continue
+		}
+		if line == "GIT binary patch" {
+			file.Binary = true
+			file.BinaryPatch = append(file.BinaryPatch, line)
+			continue
+		}
+		file.Header = append(file.Header, line)
+		applyHeaderLine(file, line)
+	}
+	return patch
+}
+
+func consumeHunkLine(hunk *Hunk, file *File, line string, counts bool, oldRem, newRem *int) bool {
+	if line == "" {
+		return false
+	}
+	kind := LineKind(line[0])
+	if kind == LineNoNewline {
+		hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: line[1:]})
+		return true
+	}
+	if counts && *oldRem <= 0 && *newRem <= 0 {
+		return false
+	}
+	switch kind {
+	case LineContext:
+		*oldRem--
+		*newRem--
+	case LineAdded:
+		*newRem--
+		file.Added++
+	case LineRemoved:
+		*oldRem--
+		file.Removed++
+	default:
+		return false
+	}
+	hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: line[1:]})
+	return true
+}
+
+func parseHunkHeader(line string) *Hunk {
+	hunk := &Hunk{Header: line, OldLines: -1, NewLines: -1}
+	match := hunkHeaderExpr.FindStringSubmatch(line)
+	if match == nil {
+		return hunk
+	}
+	hunk.OldStart = atoiDefault(match[1], 0)
+	hunk.OldLines = atoiDefault(match[2], 1)
+	hunk.NewStart = atoiDefault(match[3], 0)
+	hunk.NewLines = atoiDefault(match[4], 1)
+	hunk.Section = match[5]
+	return hunk
+}
+
+func newGitFile(line string) *File {
+	file := &File{Status: StatusModified, Header: []string{line}}
+	oldPath, newPath := parseGitPaths(strings.TrimPrefix(line, "diff --git "))
+	file.OldPath = oldPath
+	file.NewPath = newPath
+	return file
+}
+
+func isGitHeader(file *File) bool {
+	return len(file.Header) > 0 && strings.HasPrefix(file.Header[0], "diff --git ")
+}
+
+func applyHeaderLine(file *File, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		file.Status = StatusAdded
+		file.NewMode = strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		file.Status = StatusDeleted
+		file.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		file.OldMode = strings.TrimPrefix(line, "old mode ")
+		updateTypeChange(file)
+	case strings.HasPrefix(line, "new mode "):
+		file.NewMode = strings.TrimPrefix(line, "new mode ")
+		updateTypeChange(file)
+	case strings.HasPrefix(line, "similarity index "):
+		file.Similarity = atoiDefault(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"), 0)
+	case strings.HasPrefix(line, "rename from "):
+		file.Status = StatusRenamed
+		file.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		file.Status = StatusRenamed
+		file.NewPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		file.Status = StatusCopied
+		file.OldPath = unquotePath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		file.Status = StatusCopied
+		file.NewPath = unquotePath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "index "):
+		fields := strings.Fields(line)
+		if len(fields) == 3 && file.OldMode == "" && file.NewMode == "" {
+			file.OldMode = fields[2]
+			file.NewMode = fields[2]
+		}
+	case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"):
+		file.Binary = true
+	case strings.HasPrefix(line, "--- "):
+		if path, ok := stripPrefixPath(strings.TrimPrefix(line, "--- ")); ok {
+			file.OldPath = path
+		} else if file.Status == StatusModified {
+			file.Status = StatusAdded
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if path, ok := stripPrefixPath(strings.TrimPrefix(line, "+++ ")); ok {
+			file.NewPath = path
+		} else if file.Status == StatusModified {
+			file.Status = StatusDeleted
+		}
+	}
+}
+
+func updateTypeChange(file *File) {
+	if file.OldMode == "" || file.NewMode == "" || file.Status != StatusModified {
+		return
+	}
+	if fileType(file.OldMode) != fileType(file.NewMode) {
+		file.Status = StatusTypeChanged
+	}
+}
+
+func fileType(mode string) string {
+	if len(mode) < 3 {
+		return mode
+	}
+	return mode[:len(mode)-3]
+}
+
+// parseGitPaths splits the "a/old b/new" part of a "diff --git" line. Paths
+// with spaces are ambiguous, so the symmetric split is preferred and later
+// ---/+++ or rename headers override the result.
+func parseGitPaths(rest string) (string, string) {
+	if strings.HasPrefix(rest, `"`) {
+		oldPath, remainder, ok := cutQuoted(rest)
+		if ok {
+			return stripSidePrefix(oldPath), stripSidePrefix(unquotePath(strings.TrimSpace(remainder)))
+		}
+	}
+	if len(rest)%2 == 1 {
+		half := len(rest) / 2
+		left, right := rest[:half], rest[half+1:]
+		if rest[half] == ' ' && stripSidePrefix(left) == stripSidePrefix(right) {
+			return stripSidePrefix(left), stripSidePrefix(right)
+		}
+	}
+	if idx := strings.LastIndex(rest, " b/"); idx >= 0 {
+		return stripSidePrefix(rest[:idx]), stripSidePrefix(unquotePath(rest[idx+1:]))
+	}
+	if idx := strings.Index(rest, " "); idx >= 0 {
+		return stripSidePrefix(rest[:idx]), stripSidePrefix(rest[idx+1:])
+	}
+	return stripSidePrefix(rest), stripSidePrefix(rest)
+}
+
+func cutQuoted(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			unquoted, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+			return unquoted, s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func stripPrefixPath(value string) (string, bool) {
+	// git appends a tab after paths containing spaces in ---/+++ lines.
+	value = strings.TrimSuffix(value, "\t")
+	if idx := strings.Index(value, "\t"); idx >= 0 {
+		value = value[:idx]
+	}
+	value = unquotePath(value)
+	if value == "/dev/null" {
+		return "", false
+	}
+	return stripSidePrefix(value), true
+}
+
+func stripSidePrefix(path string) string {
+	for _, prefix := range []string{"a/", "b/"} {
+		if strings.HasPrefix(path, prefix) {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
+
+func unquotePath(path string) string {
+	if len(path) >= 2 && strings.HasPrefix(path, `"`) && strings.HasSuffix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
+
+func atoiDefault(value string, fallback int) int {
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func splitLines(s string) []string {
+	trimmed := strings.TrimSuffix(s, "\n")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "\n")
+}
@@ -0,0 +1,129 @@
+package gitdiff
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestParseGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden inputs found")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".diff")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch := Parse(string(data))
+			if got := patch.String(); got != string(data) {
+				t.Fatalf("round trip mismatch\n--- got ---\n%s\n--- want ---\n%s", got, data)
+			}
+
+			got := describe(patch)
+			goldenPath := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(want) {
+				t.Fatalf("golden mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+			}
+		})
+	}
+}
+
+func describe(patch *Patch) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "preamble %d lines\n", len(patch.Preamble))
+	for _, file := range patch.Files {
+		fmt.Fprintf(&b, "file %s\n", file.DisplayPath())
+		fmt.Fprintf(&b, "  status=%s old=%q new=%q old_mode=%q new_mode=%q similarity=%d binary=%v\n",
+			file.Status, file.OldPath, file.NewPath, file.OldMode, file.NewMode, file.Similarity, file.Binary)
+		fmt.Fprintf(&b, "  added=%d removed=%d header=%d binary_patch=%d trailer=%d\n",
+			file.Added, file.Removed, len(file.Header), len(file.BinaryPatch), len(file.Trailer))
+		for _, hunk := range file.Hunks {
+			fmt.Fprintf(&b, "  hunk -%d,%d +%d,%d section=%q\n", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines, hunk.Section)
+			for _, line := range hunk.Lines {
+				fmt.Fprintf(&b, "    %c %q\n", line.Kind, line.Text)
+			}
+		}
+	}
+	return b.String()
+}
+
+func TestParseKeepsUnrecognizedTextInPreamble(t *testing.T) {
+	patch := Parse("a\nb\nc")
+	if len(patch.Files) != 0 || len(patch.Preamble) != 3 {
+		t.Fatalf("unexpected parse: %+v", patch)
+	}
+	if patch.String() != "a\nb\nc" {
+		t.Fatalf("round trip mismatch: %q", patch.String())
+	}
+}
+
+func TestTruncateMarksPartialFilesAndDropsRest(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "add_delete_mode.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := Parse(string(data))
+	kept, omitted := patch.Truncate(10)
+	if kept.LineCount() != 10 {
+		t.Fatalf("expected 10 kept lines, got %d", kept.LineCount())
+	}
+	if omitted != patch.LineCount()-10 {
+		t.Fatalf("unexpected omitted count %d", omitted)
+	}
+	if len(kept.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(kept.Files))
+	}
+	if kept.Files[0].Truncated || !kept.Files[1].Truncated {
+		t.Fatalf("unexpected truncation flags: %v %v", kept.Files[0].Truncated, kept.Files[1].Truncated)
+	}
+	if kept.Files[1].Removed != 2 {
+		t.Fatalf("expected truncated file to keep full counts, got %d", kept.Files[1].Removed)
+	}
+	if patch.Files[1].Truncated {
+		t.Fatal("Truncate must not modify the original patch")
+	}
+}
+
+func TestTruncateWithinLimitReturnsOriginal(t *testing.T) {
+	patch := Parse("a\nb\n")
+	kept, omitted := patch.Truncate(2)
+	if omitted != 0 || kept != patch {
+		t.Fatalf("expected original patch, omitted=%d", omitted)
+	}
+}
+
+func TestFilterSplitsKeptAndRemovedFiles(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "add_delete_mode.diff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := Parse(string(data))
+	kept, removed := patch.Filter(func(file *File) bool { return file.Path() != "gone.txt" })
+	if len(kept.Files) != 3 || len(removed) != 1 || removed[0].Path() != "gone.txt" {
+		t.Fatalf("unexpected filter result: kept=%d removed=%v", len(kept.Files), removed)
+	}
+	if strings.Contains(kept.String(), "gone.txt") {
+		t.Fatalf("removed file still rendered:\n%s", kept.String())
+	}
+}
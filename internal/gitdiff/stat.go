@@ -0,0 +1,131 @@
+package gitdiff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FileStat is one entry of `git diff --name-status` merged with the matching
+// `git diff --numstat` counts. It describes every changed file, including
+// files whose hunks were later truncated or compacted out of the patch.
+type FileStat struct {
+	Path       string
+	OldPath    string
+	Status     Status
+	Similarity int
+	Added      int
+	Removed    int
+	Binary     bool
+	// Omitted explains why the file's hunks were left out of the patch sent
+	// to the model, for example because it matched an ignore pattern.
+	Omitted string
+}
+
+func (s FileStat) DisplayPath() string {
+	if s.OldPath != "" && s.OldPath != s.Path {
+		return s.OldPath + " => " + s.Path
+	}
+	return s.Path
+}
+
+// ParseNameStatus parses `git diff --name-status -z` output.
+func ParseNameStatus(output string) ([]FileStat, error) {
+	fields := splitNUL(output)
+	stats := make([]FileStat, 0, len(fields)/2)
+	for i := 0; i < len(fields); {
+		code := fields[i]
+		i++
+		if code == "" {
+			return nil, fmt.Errorf("empty status in name-status output")
+		}
+		stat := FileStat{Status: Status(code[:1])}
+		if len(code) > 1 {
+			stat.Similarity, _ = strconv.Atoi(code[1:])
+		}
+		if i >= len(fields) {
+			return nil, fmt.Errorf("missing path for status %q", code)
+		}
+		if stat.Status == StatusRenamed || stat.Status == StatusCopied {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing destination path for status %q", code)
+			}
+			stat.OldPath = fields[i]
+			stat.Path = fields[i+1]
+			i += 2
+		} else {
+			stat.Path = fields[i]
+			i++
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// ApplyNumstat fills Added, Removed and Binary from `git diff --numstat -z`
+// output, matching entries by destination path.
+func ApplyNumstat(stats []FileStat, output string) error {
+	byPath := make(map[string]*FileStat, len(stats))
+	for i := range stats {
+		byPath[stats[i].Path] = &stats[i]
+	}
+
+	fields := splitNUL(output)
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) != 3 {
+			return fmt.Errorf("malformed numstat entry %q", fields[i])
+		}
+		path := parts[2]
+		if path == "" {
+			// Renames and copies are followed by separate old and new paths.
+			if i+2 >= len(fields) {
+				return fmt.Errorf("missing rename paths in numstat output")
+			}
+			path = fields[i+2]
+			i += 2
+		}
+		stat, ok := byPath[path]
+		if !ok {
+			continue
+		}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+			continue
+		}
+		stat.Added, _ = strconv.Atoi(parts[0])
+		stat.Removed, _ = strconv.Atoi(parts[1])
+	}
+	return nil
+}
+
+// FileStats summarizes the patch's files the way ParseNameStatus and
+// ApplyNumstat summarize git's output, for patches git did not produce here.
+// Counts are taken from the hunks, so call it before anything is collapsed
+// or truncated away.
+func (p *Patch) FileStats() []FileStat {
+	stats := make([]FileStat, 0, len(p.Files))
+	for _, file := range p.Files {
+		stat := FileStat{
+			Path:    file.Path(),
+			Status:  file.Status,
+			Added:   file.Added,
+			Removed: file.Removed,
+			Binary:  file.Binary,
+		}
+		if file.Status == StatusRenamed || file.Status == StatusCopied {
+			stat.OldPath = file.OldPath
+			stat.Similarity = file.Similarity
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+func splitNUL(output string) []string {
+	output = strings.TrimSuffix(output, "\x00")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\x00")
+}
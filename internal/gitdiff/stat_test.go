@@ -0,0 +1,85 @@
+package gitdiff
+
+import "testing"
+
+func TestParseNameStatusAndApplyNumstat(t *testing.T) {
+	nameStatus := "D\x00b.txt\x00M\x00bin.dat\x00R097\x00a.txt\x00c.txt\x00A\x00d.txt\x00"
+	numstat := "0\t1\tb.txt\x00-\t-\tbin.dat\x001\t0\t\x00a.txt\x00c.txt\x001\t0\td.txt\x00"
+
+	stats, err := ParseNameStatus(nameStatus)
+	if err != nil {
+		t.Fatalf("ParseNameStatus() error = %v", err)
+	}
+	if err := ApplyNumstat(stats, numstat); err != nil {
+		t.Fatalf("ApplyNumstat() error = %v", err)
+	}
+
+	want := []FileStat{
+		{Path: "b.txt", Status: StatusDeleted, Removed: 1},
+		{Path: "bin.dat", Status: StatusModified, Binary: true},
+		{Path: "c.txt", OldPath: "a.txt", Status: StatusRenamed, Similarity: 97, Added: 1},
+		{Path: "d.txt", Status: StatusAdded, Added: 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %d stats, want %d: %+v", len(stats), len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Fatalf("stat %d = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+	if got := stats[2].DisplayPath(); got != "a.txt => c.txt" {
+		t.Fatalf("unexpected display path %q", got)
+	}
+}
+
+func TestParseNameStatusRejectsMissingPath(t *testing.T) {
+	if _, err := ParseNameStatus("R100\x00only-old\x00"); err == nil {
+		t.Fatal("expected error for rename without destination")
+	}
+}
+
+func TestPatchFileStatsMatchGitSummary(t *testing.T) {
+	patch := Parse(`diff --git a/b.txt b/b.txt
+deleted file mode 100644
+index 3b18e51..0000000
+--- a/b.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-hello
+diff --git a/bin.dat b/bin.dat
+index 1b2c3d4..5e6f7a8 100644
+Binary files a/bin.dat and b/bin.dat differ
+diff --git a/a.txt b/c.txt
+similarity index 97%
+rename from a.txt
+rename to c.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/c.txt
+@@ -1,2 +1,3 @@
+ one
+ two
++three
+--- d.txt.orig
++++ d.txt
+@@ -0,0 +1 @@
++new
+`)
+
+	want := []FileStat{
+		{Path: "b.txt", Status: StatusDeleted, Removed: 1},
+		{Path: "bin.dat", Status: StatusModified, Binary: true},
+		{Path: "c.txt", OldPath: "a.txt", Status: StatusRenamed, Similarity: 97, Added: 1},
+		{Path: "d.txt", Status: StatusModified, Added: 1},
+	}
+	stats := patch.FileStats()
+	if len(stats) != len(want) {
+		t.Fatalf("got %d stats, want %d: %+v", len(stats), len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Fatalf("stat %d = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package gitdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitlinkMode is the mode git records for a submodule entry.
+const GitlinkMode = "160000"
+
+const subprojectPrefix = "Subproject commit "
+
+// Submodule is a change to the commit a submodule entry points at.
+type Submodule struct {
+	Path string
+	// Old and New are full commit ids; Old is empty for an added submodule
+	// and New for a removed one.
+	Old string
+	New string
+	// Log is `git log --oneline Old..New` from the submodule's checkout, when
+	// it is available locally.
+	Log []string
+	// LogOmitted counts log entries left out of Log.
+	LogOmitted int
+}
+
+// IsGitlink reports whether either side of the file is a submodule.
+func (f *File) IsGitlink() bool {
+	return f.OldMode == GitlinkMode || f.NewMode == GitlinkMode
+}
+
+// Submodules lists the gitlink changes in the patch, reading the commit ids
+// from the "Subproject commit" lines git prints for them.
+func (p *Patch) Submodules() []Submodule {
+	var submodules []Submodule
+	for _, file := range p.Files {
+		if !file.IsGitlink() {
+			continue
+		}
+		sub := Submodule{Path: file.Path()}
+		for _, hunk := range file.Hunks {
+			for _, line := range hunk.Lines {
+				if !strings.HasPrefix(line.Text, subprojectPrefix) {
+					continue
+				}
+				id := strings.TrimSpace(strings.TrimPrefix(line.Text, subprojectPrefix))
+				switch line.Kind {
+				case LineRemoved:
+					sub.Old = id
+				case LineAdded:
+					sub.New = id
+				}
+			}
+		}
+		// A file replaced by a submodule (or the reverse) only has one side
+		// that is a gitlink.
+		if file.OldMode != GitlinkMode {
+			sub.Old = ""
+		}
+		if file.NewMode != GitlinkMode {
+			sub.New = ""
+		}
+		submodules = append(submodules, sub)
+	}
+	return submodules
+}
+
+// Summary describes the change in one line, e.g. "bump lib from abc1234 to
+// def5678".
+func (s Submodule) Summary() string {
+	switch {
+	case s.Old == "":
+		return fmt.Sprintf("add submodule %s at %s", s.Path, shortID(s.New))
+	case s.New == "":
+		return fmt.Sprintf("remove submodule %s (was at %s)", s.Path, shortID(s.Old))
+	}
+	return fmt.Sprintf("bump %s from %s to %s", s.Path, shortID(s.Old), shortID(s.New))
+}
+
+func shortID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
@@ -0,0 +1,54 @@
+package gitdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubmodulesReadsGitlinkChanges(t *testing.T) {
+	patch := Parse(`diff --git a/lib b/lib
+index 1111111..2222222 160000
+--- a/lib
++++ b/lib
+@@ -1 +1 @@
+-Subproject commit 1111111111111111111111111111111111111111
++Subproject commit 2222222222222222222222222222222222222222
+diff --git a/vendor/new b/vendor/new
+new file mode 160000
+index 0000000..3333333
+--- /dev/null
++++ b/vendor/new
+@@ -0,0 +1 @@
++Subproject commit 3333333333333333333333333333333333333333
+diff --git a/old b/old
+deleted file mode 160000
+index 4444444..0000000
+--- a/old
++++ /dev/null
+@@ -1 +0,0 @@
+-Subproject commit 4444444444444444444444444444444444444444
+diff --git a/main.go b/main.go
+index 5555555..6666666 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-Subproject commit is just text here
++package main
+`)
+	got := patch.Submodules()
+	var summaries []string
+	for _, sub := range got {
+		summaries = append(summaries, sub.Summary())
+	}
+	want := []string{
+		"bump lib from 1111111 to 2222222",
+		"add submodule vendor/new at 3333333",
+		"remove submodule old (was at 4444444)",
+	}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Fatalf("Submodules() summaries = %q, want %q", summaries, want)
+	}
+	if got[0].Old != "1111111111111111111111111111111111111111" || got[0].New != "2222222222222222222222222222222222222222" {
+		t.Fatalf("expected full commit ids, got %+v", got[0])
+	}
+}
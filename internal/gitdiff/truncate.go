@@ -0,0 +1,78 @@
+package gitdiff
+
+// Truncate returns a copy of the patch holding at most maxLines rendered
+// lines, along with the number of lines left out. Files cut partway through
+// are marked Truncated and keep their full Added/Removed counts; files that
+// did not fit at all are dropped.
+func (p *Patch) Truncate(maxLines int) (*Patch, int) {
+	total := p.LineCount()
+	if maxLines <= 0 || total <= maxLines {
+		return p, 0
+	}
+
+	budget := maxLines
+	take := func(lines []string) []string {
+		if budget <= 0 {
+			return nil
+		}
+		if len(lines) > budget {
+			lines = lines[:budget]
+		}
+		budget -= len(lines)
+		return append([]string(nil), lines...)
+	}
+
+	out := &Patch{}
+	out.Preamble = take(p.Preamble)
+	for _, file := range p.Files {
+		if budget <= 0 {
+			break
+		}
+		kept := truncateFile(file, take, &budget)
+		out.Files = append(out.Files, kept)
+	}
+	return out, total - out.LineCount()
+}
+
+func truncateFile(file *File, take func([]string) []string, budget *int) *File {
+	kept := *file
+	kept.Header = take(file.Header)
+	kept.BinaryPatch = take(file.BinaryPatch)
+	kept.Hunks = nil
+	kept.Trailer = nil
+
+	for _, hunk := range file.Hunks {
+		if *budget <= 0 {
+			break
+		}
+		*budget--
+		keptHunk := *hunk
+		keptHunk.Lines = nil
+		for _, line := range hunk.Lines {
+			if *budget <= 0 {
+				break
+			}
+			*budget--
+			keptHunk.Lines = append(keptHunk.Lines, line)
+		}
+		kept.Hunks = append(kept.Hunks, &keptHunk)
+	}
+	kept.Trailer = take(file.Trailer)
+	kept.Truncated = len(kept.Lines()) < len(file.Lines())
+	return &kept
+}
+
+// Filter splits the patch into files for which keep returns true and the
+// files that were removed. The preamble stays with the kept patch.
+func (p *Patch) Filter(keep func(*File) bool) (*Patch, []*File) {
+	out := &Patch{Preamble: p.Preamble, trailingNewline: p.trailingNewline}
+	var removed []*File
+	for _, file := range p.Files {
+		if keep(file) {
+			out.Files = append(out.Files, file)
+		} else {
+			removed = append(removed, file)
+		}
+	}
+	return out, removed
+}
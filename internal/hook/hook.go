@@ -0,0 +1,184 @@
+// Package hook installs and removes gitcomm's git hooks without disturbing
+// hooks written by the user or by hook managers.
+package hook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	PrepareCommitMsg = "prepare-commit-msg"
+
+	beginMarker = "# --- BEGIN GITCOMM HOOK ---"
+	endMarker   = "# --- END GITCOMM HOOK ---"
+	// chainedSuffix is appended to an existing non-shell hook that gitcomm
+	// moves aside and calls from its own wrapper.
+	chainedSuffix = ".gitcomm-chained"
+)
+
+// chainCommand runs the hook moved aside by Install. Like git, it runs the
+// file through sh when it has no shebang, and also when it is not
+// executable, so exec never fails and blocks the commit.
+const chainCommand = `_gitcomm_chained="$0` + chainedSuffix + `"
+_gitcomm_first=
+IFS= read -r _gitcomm_first < "$_gitcomm_chained"
+case "$_gitcomm_first" in
+'#!'*) [ -x "$_gitcomm_chained" ] && exec "$_gitcomm_chained" "$@" ;;
+esac
+exec sh "$_gitcomm_chained" "$@"
+`
+
+var shellShebangExpr = regexp.MustCompile(`^#!\s*(/usr/bin/env\s+)?(/\S*/)?(sh|bash|dash|zsh|ksh)\b`)
+
+// Block returns the marked section that runs `gitcomm hook run` for name.
+// It never exits, so whatever follows in the hook still runs, and a failure
+// to generate a message never blocks the commit.
+func Block(name, executable string) string {
+	return strings.Join([]string{
+		beginMarker,
+		"# Managed by `gitcomm hook install`; remove with `gitcomm hook uninstall`.",
+		"_gitcomm=" + shellQuote(executable),
+		`if [ ! -x "$_gitcomm" ]; then _gitcomm=$(command -v gitcomm 2>/dev/null); fi`,
+		`if [ -n "$_gitcomm" ] && [ "${GITCOMM_HOOK:-1}" != "0" ]; then`,
+		`  "$_gitcomm" hook run ` + name + ` "$@" || echo >&2 "gitcomm: hook failed; continuing without a generated message"`,
+		"fi",
+		endMarker,
+	}, "\n")
+}
+
+// Installed reports whether the hook file at path contains the gitcomm block.
+func Installed(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(data), beginMarker)
+}
+
+// Install adds the gitcomm block to the hook at path. A missing hook is
+// created; an existing shell hook gets the block right after its shebang, or
+// at the top when it has none, since git runs those with sh; any other
+// executable is moved aside and chained from a new shell wrapper. It returns
+// a short description of what was done.
+func Install(path, name, executable string) (string, error) {
+	block := Block(name, executable)
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", err
+		}
+		return "created", writeHook(path, "#!/bin/sh\n"+block+"\n")
+	case err != nil:
+		return "", err
+	}
+
+	content := string(data)
+	if strings.Contains(content, beginMarker) {
+		updated, _ := removeBlock(content)
+		return "updated", writeHook(path, insertBlock(updated, block))
+	}
+	if isShellScript(content) {
+		return "added to existing hook", writeHook(path, insertBlock(content, block))
+	}
+
+	chained := path + chainedSuffix
+	if _, err := os.Stat(chained); err == nil {
+		return "", fmt.Errorf("%s already exists; remove it or merge it into %s first", chained, path)
+	}
+	if err := os.Rename(path, chained); err != nil {
+		return "", err
+	}
+	wrapper := "#!/bin/sh\n" + block + "\n" + chainCommand
+	if err := writeHook(path, wrapper); err != nil {
+		os.Rename(chained, path)
+		return "", err
+	}
+	return "chained existing hook", nil
+}
+
+// Uninstall removes the gitcomm block from the hook at path, deleting hooks
+// gitcomm created and putting back a hook it chained. It reports whether a
+// block was found.
+func Uninstall(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	content, found := removeBlock(string(data))
+	if !found {
+		return false, nil
+	}
+
+	chained := path + chainedSuffix
+	if _, err := os.Stat(chained); err == nil {
+		return true, os.Rename(chained, path)
+	}
+	if isEmptyScript(content) {
+		return true, os.Remove(path)
+	}
+	return true, writeHook(path, content)
+}
+
+func insertBlock(content, block string) string {
+	if strings.HasPrefix(content, "#!") {
+		shebang, rest, _ := strings.Cut(content, "\n")
+		return shebang + "\n" + block + "\n" + rest
+	}
+	if strings.TrimSpace(content) == "" {
+		return "#!/bin/sh\n" + block + "\n"
+	}
+	// git runs a hook without a shebang with sh, so the block works as is
+	// and uninstalling leaves the file exactly as it was.
+	return block + "\n" + content
+}
+
+// removeBlock strips the marked section, and reports whether it was present.
+func removeBlock(content string) (string, bool) {
+	start := strings.Index(content, beginMarker)
+	if start < 0 {
+		return content, false
+	}
+	end := strings.Index(content[start:], endMarker)
+	if end < 0 {
+		return content, false
+	}
+	end += start + len(endMarker)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + content[end:], true
+}
+
+// isShellScript reports whether content is run by a shell: it has a shell
+// shebang, or none at all.
+func isShellScript(content string) bool {
+	first, _, _ := strings.Cut(content, "\n")
+	return !strings.HasPrefix(first, "#!") || shellShebangExpr.MatchString(first)
+}
+
+func isEmptyScript(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+func writeHook(path, content string) error {
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file; hooks must be executable.
+	return os.Chmod(path, 0o755)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,208 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallCreatesAndUninstallRemovesHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks", PrepareCommitMsg)
+	action, err := Install(path, PrepareCommitMsg, "/opt/gitcomm")
+	if err != nil || action != "created" {
+		t.Fatalf("Install() = %q, %v", action, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&0o111 == 0 {
+		t.Fatalf("hook should be executable: %v %v", info, err)
+	}
+	if !Installed(path) {
+		t.Fatal("expected Installed() after install")
+	}
+	if action, err := Install(path, PrepareCommitMsg, "/opt/gitcomm"); err != nil || action != "updated" {
+		t.Fatalf("reinstall = %q, %v", action, err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), beginMarker) != 1 {
+		t.Fatalf("reinstall should not duplicate the block:\n%s", data)
+	}
+
+	if found, err := Uninstall(path); err != nil || !found {
+		t.Fatalf("Uninstall() = %v, %v", found, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("hook created by gitcomm should be deleted on uninstall")
+	}
+	if found, err := Uninstall(path); err != nil || found {
+		t.Fatalf("second Uninstall() = %v, %v", found, err)
+	}
+}
+
+func TestInstallKeepsExistingShellHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PrepareCommitMsg)
+	existing := "#!/usr/bin/env sh\n# --- BEGIN BEADS INTEGRATION ---\nbd hooks run prepare-commit-msg \"$@\"\n# --- END BEADS INTEGRATION ---\n"
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if action, err := Install(path, PrepareCommitMsg, "/opt/gitcomm"); err != nil || action != "added to existing hook" {
+		t.Fatalf("Install() = %q, %v", action, err)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.HasPrefix(content, "#!/usr/bin/env sh\n"+beginMarker) || !strings.HasSuffix(content, existing[len("#!/usr/bin/env sh\n"):]) {
+		t.Fatalf("unexpected hook:\n%s", content)
+	}
+
+	if found, err := Uninstall(path); err != nil || !found {
+		t.Fatalf("Uninstall() = %v, %v", found, err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != existing {
+		t.Fatalf("uninstall should restore the original hook, got:\n%s", data)
+	}
+}
+
+func TestInstallChainsNonShellHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PrepareCommitMsg)
+	existing := "#!/usr/bin/env python3\nprint('hi')\n"
+	if err := os.WriteFile(path, []byte(existing), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if action, err := Install(path, PrepareCommitMsg, "/opt/gitcomm"); err != nil || action != "chained existing hook" {
+		t.Fatalf("Install() = %q, %v", action, err)
+	}
+	chained, err := os.ReadFile(path + chainedSuffix)
+	if err != nil || string(chained) != existing {
+		t.Fatalf("original hook should be moved aside: %q, %v", chained, err)
+	}
+	if found, err := Uninstall(path); err != nil || !found {
+		t.Fatalf("Uninstall() = %v, %v", found, err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != existing {
+		t.Fatalf("uninstall should put the chained hook back, got:\n%s", data)
+	}
+}
+
+func TestInstallAddsBlockToHookWithoutShebang(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, PrepareCommitMsg)
+	log := filepath.Join(dir, "log")
+	// A husky-style hook: no shebang, so git runs it with sh.
+	existing := "echo husky \"$1\" >> \"" + log + "\"\n"
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fake := filepath.Join(dir, "fake-gitcomm")
+	if err := os.WriteFile(fake, []byte("#!/bin/sh\necho gitcomm >> \""+log+"\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if action, err := Install(path, PrepareCommitMsg, fake); err != nil || action != "added to existing hook" {
+		t.Fatalf("Install() = %q, %v", action, err)
+	}
+	if _, err := os.Stat(path + chainedSuffix); !os.IsNotExist(err) {
+		t.Fatal("a hook without a shebang should not be chained")
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), beginMarker) || !strings.HasSuffix(string(data), existing) {
+		t.Fatalf("unexpected hook:\n%s", data)
+	}
+
+	if out, err := exec.Command("sh", path, "COMMIT_EDITMSG").CombinedOutput(); err != nil {
+		t.Fatalf("hook failed: %v\n%s", err, out)
+	}
+	if got, _ := os.ReadFile(log); string(got) != "gitcomm\nhusky COMMIT_EDITMSG\n" {
+		t.Fatalf("unexpected log %q", got)
+	}
+
+	if found, err := Uninstall(path); err != nil || !found {
+		t.Fatalf("Uninstall() = %v, %v", found, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != existing {
+		t.Fatalf("uninstall should restore the original hook, got:\n%s", data)
+	}
+}
+
+func TestChainedHookRunsThroughShWhenItCannotBeExecuted(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, PrepareCommitMsg)
+	out := filepath.Join(dir, "out")
+	// Not executable, so exec would fail; sh skips the shebang line.
+	existing := "#!/usr/bin/env false\necho chained \"$@\" > \"" + out + "\"\n"
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if action, err := Install(path, PrepareCommitMsg, filepath.Join(dir, "missing-gitcomm")); err != nil || action != "chained existing hook" {
+		t.Fatalf("Install() = %q, %v", action, err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		mode    os.FileMode
+	}{
+		{"not executable", existing, 0o644},
+		{"no shebang", "echo chained \"$@\" > \"" + out + "\"\n", 0o755},
+		{"executable with shebang", "#!/bin/sh\necho chained \"$@\" > \"" + out + "\"\n", 0o755},
+	} {
+		if err := os.WriteFile(path+chainedSuffix, []byte(tc.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path+chainedSuffix, tc.mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(out)
+		if output, err := exec.Command(path, "COMMIT_EDITMSG", "message").CombinedOutput(); err != nil {
+			t.Fatalf("%s: wrapper failed: %v\n%s", tc.name, err, output)
+		}
+		if got, _ := os.ReadFile(out); string(got) != "chained COMMIT_EDITMSG message\n" {
+			t.Fatalf("%s: chained hook got %q", tc.name, got)
+		}
+	}
+}
+
+func TestHookFailsOpenAndPassesArguments(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "fake-gitcomm")
+	script := "#!/bin/sh\necho \"$*\" > \"" + filepath.Join(dir, "args") + "\"\nexit 1\n"
+	if err := os.WriteFile(fake, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, PrepareCommitMsg)
+	if _, err := Install(path, PrepareCommitMsg, fake); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(path, "COMMIT_EDITMSG", "message").CombinedOutput()
+	if err != nil {
+		t.Fatalf("hook should fail open, got %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "continuing without a generated message") {
+		t.Fatalf("expected a warning, got %q", out)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if strings.TrimSpace(string(args)) != "hook run prepare-commit-msg COMMIT_EDITMSG message" {
+		t.Fatalf("unexpected arguments %q", args)
+	}
+
+	cmd := exec.Command(path, "COMMIT_EDITMSG")
+	cmd.Env = append(os.Environ(), "GITCOMM_HOOK=0")
+	os.Remove(filepath.Join(dir, "args"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("disabled hook failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "args")); !os.IsNotExist(err) {
+		t.Fatal("GITCOMM_HOOK=0 should skip gitcomm")
+	}
+}
@@ -0,0 +1,146 @@
+// Package ignore matches repository paths against gitignore-style patterns.
+package ignore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FileName is the repo-level file holding patterns for paths that should be
+// left out of the diff sent to the model.
+const FileName = ".gitcommignore"
+
+type rule struct {
+	pattern string
+	expr    *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+type Matcher struct {
+	rules []rule
+}
+
+// New compiles patterns in order; like .gitignore, later patterns override
+// earlier ones and a leading "!" re-includes a path.
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		if r, ok := compile(pattern); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	return m
+}
+
+// ParseLines splits the contents of an ignore file into patterns.
+func ParseLines(content string) []string {
+	return strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+}
+
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match reports whether path (slash separated, relative to the repository
+// root) is excluded. A path inside an excluded directory is always excluded,
+// matching git's behaviour.
+func (m *Matcher) Match(path string) bool {
+	if m.Empty() {
+		return false
+	}
+	path = strings.TrimPrefix(path, "/")
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchOne(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.matchOne(path, false)
+}
+
+func (m *Matcher) matchOne(path string, isDir bool) bool {
+	matched := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.expr.MatchString(path) {
+			matched = !r.negate
+		}
+	}
+	return matched
+}
+
+func compile(pattern string) (rule, bool) {
+	original := pattern
+	pattern = strings.TrimRight(pattern, " \t")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule{}, false
+	}
+
+	r := rule{pattern: original}
+	switch {
+	case strings.HasPrefix(pattern, "!"):
+		r.negate = true
+		pattern = pattern[1:]
+	case strings.HasPrefix(pattern, `\!`), strings.HasPrefix(pattern, `\#`):
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		r.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if pattern == "" {
+		return rule{}, false
+	}
+
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && pattern[i:] == "**" && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	expr, err := regexp.Compile(b.String())
+	if err != nil {
+		return rule{}, false
+	}
+	r.expr = expr
+	return r, true
+}
@@ -0,0 +1,67 @@
+package ignore
+
+import "testing"
+
+func TestMatcherGitignoreSemantics(t *testing.T) {
+	m := New(ParseLines(`# lockfiles and generated output
+go.sum
+*.lock
+/dist
+vendor/
+**/__snapshots__/**
+docs/**/*.png
+!important.lock
+`))
+
+	cases := map[string]bool{
+		"go.sum":                        true,
+		"tools/go.sum":                  true,
+		"yarn.lock":                     true,
+		"web/package.lock":              true,
+		"important.lock":                false,
+		"dist/app.js":                   true,
+		"web/dist/app.js":               false,
+		"vendor/github.com/x/y.go":      true,
+		"pkg/vendor/z.go":               true,
+		"vendor":                        false,
+		"ui/__snapshots__/button.snap":  true,
+		"docs/img/deep/logo.png":        true,
+		"docs/logo.png":                 true,
+		"assets/logo.png":               false,
+		"main.go":                       false,
+		"internal/analyzer/analyzer.go": false,
+	}
+	for path, want := range cases {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMatcherCharacterClassesAndEscapes(t *testing.T) {
+	m := New([]string{"file[0-9].txt", `\#notes`, "report?.csv", "[!a]*.tmp"})
+	cases := map[string]bool{
+		"file3.txt":    true,
+		"filex.txt":    false,
+		"#notes":       true,
+		"report1.csv":  true,
+		"report12.csv": false,
+		"b.tmp":        true,
+		"a.tmp":        false,
+	}
+	for path, want := range cases {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestEmptyMatcherMatchesNothing(t *testing.T) {
+	var m *Matcher
+	if m.Match("go.sum") {
+		t.Fatal("nil matcher should not match")
+	}
+	if !New([]string{"", "# comment"}).Empty() {
+		t.Fatal("expected comments and blanks to produce no rules")
+	}
+}
@@ -3,9 +3,11 @@ package llm
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -22,17 +24,37 @@ const (
 type ClientConfig struct {
 	MaxTokens   int32
 	Temperature float32
+	// Progress receives the model and key fallback notes; nil means
+	// os.Stdout.
+	Progress io.Writer
 }
 
 type Client struct {
-	apiKey      string
+	keys        []keySlot
 	apiURL      string
 	maxTokens   int32
 	temperature float32
 	client      *http.Client
 	models      []string
+	progress    io.Writer
 }
 
+type keySlot struct {
+	entry    config.APIKeyEntry
+	value    string
+	resolved bool
+	disabled bool
+}
+
+// apiError carries the provider status so SendPrompt can tell key failures
+// (auth, credits) apart from model failures.
+type apiError struct {
+	statusCode int
+	msg        string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
 type chatResponse struct {
 	Choices []struct {
 		Message struct {
@@ -58,12 +80,12 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 		diag.Warn("llm", "continuing with runtime fallback config", "error", cfgErr)
 	}
 
-	apiKey := appConfig.OpenRouterAPIKey
-	if apiKey == "" {
+	pool := appConfig.KeyPool()
+	if len(pool) == 0 {
 		if cfgErr != nil {
 			return nil, fmt.Errorf("configuration is invalid and no OpenRouter API key is available via %s/%s: %w", config.OpenRouterAPIKeyEnvPrimary, config.OpenRouterAPIKeyEnvLegacy, cfgErr)
 		}
-		return nil, fmt.Errorf("OpenRouter API key not set in config file or %s/%s environment variables", config.OpenRouterAPIKeyEnvPrimary, config.OpenRouterAPIKeyEnvLegacy)
+		return nil, fmt.Errorf("OpenRouter API key not set in config file (open_router_api_key or api_key_command) or %s/%s environment variables", config.OpenRouterAPIKeyEnvPrimary, config.OpenRouterAPIKeyEnvLegacy)
 	}
 
 	models := config.DefaultModels
@@ -87,14 +109,24 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 		timeoutSeconds = appConfig.TimeoutSeconds
 	}
 
-	diag.Info("llm", "initialized client", "models", strings.Join(models, ","), "timeout_seconds", timeoutSeconds, "max_tokens", maxTokens, "temperature", temperature, "api_url", apiURL, "config_warning", cfgErr != nil)
+	keys := make([]keySlot, len(pool))
+	for i, entry := range pool {
+		keys[i] = keySlot{entry: entry}
+	}
+
+	progress := cfg.Progress
+	if progress == nil {
+		progress = os.Stdout
+	}
+	diag.Info("llm", "initialized client", "models", strings.Join(models, ","), "keys_count", len(keys), "timeout_seconds", timeoutSeconds, "max_tokens", maxTokens, "temperature", temperature, "api_url", apiURL, "config_warning", cfgErr != nil)
 	return &Client{
-		apiKey:      apiKey,
+		keys:        keys,
 		apiURL:      apiURL,
 		maxTokens:   maxTokens,
 		temperature: temperature,
 		client:      &http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second},
 		models:      models,
+		progress:    progress,
 	}, nil
 }
 
@@ -103,30 +135,104 @@ func (c *Client) Close() error { return nil }
 func (c *Client) SendPrompt(prompt string) (string, error) {
 	var lastErr error
 	promptBytes := len([]byte(prompt))
-	diag.Info("llm", "sending prompt", "models_count", len(c.models), "prompt_chars", len(prompt), "prompt_bytes", promptBytes, "max_tokens", c.maxTokens)
+	diag.Info("llm", "sending prompt", "models_count", len(c.models), "keys_count", len(c.keys), "prompt_chars", len(prompt), "prompt_bytes", promptBytes, "max_tokens", c.maxTokens)
 
 	for i, model := range c.models {
 		if i == 0 {
-			fmt.Printf("⚡ Using %s\n", getModelDisplayName(model))
+			fmt.Fprintf(c.progress, "⚡ Using %s\n", getModelDisplayName(model))
 		} else {
-			fmt.Printf("🔄 Falling back to %s\n", getModelDisplayName(model))
+			fmt.Fprintf(c.progress, "🔄 Falling back to %s\n", getModelDisplayName(model))
 		}
-		response, err := c.tryModel(model, prompt, i+1, len(c.models))
+		response, err := c.tryModelWithKeys(model, prompt, i+1, len(c.models))
 		if err == nil {
-			diag.Info("llm", "model succeeded", "model", model, "attempt", i+1)
 			return response, nil
 		}
 		lastErr = err
 		diag.Warn("llm", "model attempt failed", "model", model, "attempt", i+1, "error", err)
 		if i < len(c.models)-1 {
-			fmt.Printf("⚠️  %s failed, trying next model...\n", getModelDisplayName(model))
+			fmt.Fprintf(c.progress, "⚠️  %s failed, trying next model...\n", getModelDisplayName(model))
 		}
 	}
 
 	return "", fmt.Errorf("all models failed; see diagnostics log for details: %w", lastErr)
 }
 
-func (c *Client) tryModel(model, prompt string, attempt, total int) (string, error) {
+// tryModelWithKeys rotates through the key pool for a single model. Auth and
+// credit failures move on to the next key; any other failure is returned so
+// SendPrompt can fall back to the next model.
+func (c *Client) tryModelWithKeys(model, prompt string, attempt, total int) (string, error) {
+	var lastErr error
+	for i := range c.keys {
+		if c.keys[i].disabled {
+			continue
+		}
+		keyIndex := i + 1
+		apiKey, err := c.resolveKey(i)
+		if err != nil {
+			lastErr = err
+			diag.Warn("llm", "api key unavailable", "key_index", keyIndex, "error", err)
+			continue
+		}
+
+		response, err := c.tryModel(model, apiKey, prompt, attempt, total, keyIndex)
+		if err == nil {
+			diag.Info("llm", "model succeeded", "model", model, "attempt", attempt, "key_index", keyIndex)
+			return response, nil
+		}
+		lastErr = err
+
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || !isKeyStatus(apiErr.statusCode) {
+			return "", err
+		}
+		if apiErr.statusCode != http.StatusPaymentRequired {
+			c.keys[i].disabled = true
+		}
+		diag.Warn("llm", "api key rejected", "model", model, "attempt", attempt, "key_index", keyIndex, "status", apiErr.statusCode)
+		if c.hasKeyAfter(i) {
+			fmt.Fprintf(c.progress, "🔑 API key %d rejected (%d), rotating to next key...\n", keyIndex, apiErr.statusCode)
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no usable OpenRouter API keys remain")
+	}
+	return "", lastErr
+}
+
+func (c *Client) resolveKey(i int) (string, error) {
+	slot := &c.keys[i]
+	if slot.resolved {
+		return slot.value, nil
+	}
+	value, err := config.ResolveAPIKey(slot.entry)
+	if err != nil {
+		slot.disabled = true
+		return "", fmt.Errorf("API key %d: %w", i+1, err)
+	}
+	slot.value = value
+	slot.resolved = true
+	return value, nil
+}
+
+func (c *Client) hasKeyAfter(i int) bool {
+	for j := i + 1; j < len(c.keys); j++ {
+		if !c.keys[j].disabled {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeyStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) tryModel(model, apiKey, prompt string, attempt, total, keyIndex int) (string, error) {
 	requestBody, err := json.Marshal(map[string]any{
 		"model":       model,
 		"messages":    []map[string]string{{"role": "user", "content": prompt}},
@@ -138,13 +244,13 @@ func (c *Client) tryModel(model, prompt string, attempt, total int) (string, err
 		return "", fmt.Errorf("failed to marshal request body: %w", err)
 	}
 	startedAt := time.Now()
-	diag.Info("llm", "starting model attempt", "model", model, "attempt", attempt, "total_attempts", total, "request_bytes", len(requestBody), "prompt_chars", len(prompt))
+	diag.Info("llm", "starting model attempt", "model", model, "attempt", attempt, "total_attempts", total, "key_index", keyIndex, "request_bytes", len(requestBody), "prompt_chars", len(prompt))
 
 	req, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Title", "GitComm")
 
@@ -194,18 +300,18 @@ func formatAPIError(model string, statusCode int, body []byte) error {
 		base += ": " + providerMsg
 	}
 
+	msg := base
 	switch statusCode {
 	case http.StatusBadRequest:
-		return fmt.Errorf("%s. This can happen when the diff/prompt is too large or malformed", base)
+		msg = base + ". This can happen when the diff/prompt is too large or malformed"
 	case http.StatusPaymentRequired:
-		return fmt.Errorf("%s. The model may require credits or be unavailable on your OpenRouter plan", base)
+		msg = base + ". The model may require credits or be unavailable on your OpenRouter plan"
 	case http.StatusTooManyRequests:
-		return fmt.Errorf("%s. The model is rate limited right now", base)
+		msg = base + ". The model is rate limited right now"
 	case http.StatusUnauthorized, http.StatusForbidden:
-		return fmt.Errorf("OpenRouter authentication failed (%d)", statusCode)
-	default:
-		return fmt.Errorf(base)
+		msg = fmt.Sprintf("OpenRouter authentication failed (%d)", statusCode)
 	}
+	return &apiError{statusCode: statusCode, msg: msg}
 }
 
 func getModelDisplayName(model string) string {
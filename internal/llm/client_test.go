@@ -1,6 +1,9 @@
 package llm
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,8 +28,8 @@ func TestNewClientUsesRuntimeFallbackConfigWithEnv(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewClient() error = %v", err)
 	}
-	if client.apiKey != "env-key" {
-		t.Fatalf("expected env api key, got %q", client.apiKey)
+	if len(client.keys) != 1 || client.keys[0].entry.Key != "env-key" {
+		t.Fatalf("expected env api key, got %+v", client.keys)
 	}
 	if len(client.models) != len(config.DefaultModels) {
 		t.Fatalf("expected default models, got %v", client.models)
@@ -56,3 +59,152 @@ func TestFormatAPIErrorPaymentRequiredPreservesProviderReason(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func writeTestConfig(t *testing.T, cfg map[string]any) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.OpenRouterAPIKeyEnvPrimary, "")
+	t.Setenv(config.OpenRouterAPIKeyEnvLegacy, "")
+	configDir := filepath.Join(home, ".gitcomm")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendPromptRotatesKeysBeforeFallingBackToNextModel(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		auth := r.Header.Get("Authorization")
+		calls = append(calls, body.Model+" "+auth)
+		switch auth {
+		case "Bearer key-one":
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(`{"error":{"message":"insufficient credits"}}`))
+		case "Bearer key-two":
+			w.Write([]byte(`{"choices":[{"message":{"content":"feat: rotate keys"}}]}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, map[string]any{
+		"open_router_api_key": "key-one",
+		"api_keys":            []map[string]string{{"key": "key-two"}},
+		"models":              []string{"test/first", "test/second"},
+		"api_url":             server.URL,
+	})
+
+	client, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	got, err := client.SendPrompt("diff")
+	if err != nil {
+		t.Fatalf("SendPrompt() error = %v", err)
+	}
+	if got != "feat: rotate keys" {
+		t.Fatalf("unexpected response %q", got)
+	}
+	want := []string{"test/first Bearer key-one", "test/first Bearer key-two"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected call sequence %v", calls)
+	}
+}
+
+func TestSendPromptDoesNotRotateKeysOnModelFailure(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		calls = append(calls, body.Model+" "+r.Header.Get("Authorization"))
+		if body.Model == "test/first" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"fix: fallback"}}]}`))
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, map[string]any{
+		"api_keys": []map[string]string{{"key": "key-one"}, {"key": "key-two"}},
+		"models":   []string{"test/first", "test/second"},
+		"api_url":  server.URL,
+	})
+
+	client, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.SendPrompt("diff"); err != nil {
+		t.Fatalf("SendPrompt() error = %v", err)
+	}
+	want := []string{"test/first Bearer key-one", "test/second Bearer key-one"}
+	if strings.Join(calls, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected call sequence %v", calls)
+	}
+}
+
+func TestSendPromptSkipsKeysRejectedForAuth(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		auth := r.Header.Get("Authorization")
+		calls = append(calls, body.Model+" "+auth)
+		switch {
+		case auth == "Bearer bad-key":
+			w.WriteHeader(http.StatusUnauthorized)
+		case body.Model == "test/first":
+			// Not a key problem, so the next model is tried with the
+			// keys that are still enabled.
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"model not found"}}`))
+		default:
+			w.Write([]byte(`{"choices":[{"message":{"content":"fix: skip bad key"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	writeTestConfig(t, map[string]any{
+		"api_keys": []map[string]string{{"key": "bad-key"}, {"key": "good-key"}},
+		"models":   []string{"test/first", "test/second"},
+		"api_url":  server.URL,
+	})
+
+	client, err := NewClient(ClientConfig{})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	got, err := client.SendPrompt("diff")
+	if err != nil {
+		t.Fatalf("SendPrompt() error = %v (calls %v)", err, calls)
+	}
+	if got != "fix: skip bad key" {
+		t.Fatalf("SendPrompt() = %q", got)
+	}
+	want := []string{
+		"test/first Bearer bad-key",
+		"test/first Bearer good-key",
+		"test/second Bearer good-key",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,71 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// goDecls lists a Go file's functions, methods and types, and its exported
+// constants and variables.
+func goDecls(src []byte) ([]decl, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	text := func(node ast.Node) string {
+		return normalize(string(src[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset]))
+	}
+
+	var decls []decl
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				decls = append(decls, decl{kind: "method", name: receiverName(d.Recv.List[0].Type) + "." + d.Name.Name, body: text(d)})
+			} else {
+				decls = append(decls, decl{kind: "func", name: d.Name.Name, body: text(d)})
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					decls = append(decls, decl{kind: "type", name: spec.Name.Name, body: text(spec)})
+				case *ast.ValueSpec:
+					kind := "var"
+					if d.Tok == token.CONST {
+						kind = "const"
+					}
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							decls = append(decls, decl{kind: kind, name: name.Name, body: text(spec)})
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls, nil
+}
+
+// receiverName returns the type a method is declared on, without pointer
+// or type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return "?"
+		}
+	}
+}
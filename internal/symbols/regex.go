@@ -0,0 +1,123 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pattern matches the first line of a declaration. The symbol's name is
+// its non-empty submatches joined with ".", so a pattern can capture a
+// receiver or class as well as the name.
+type pattern struct {
+	kind string
+	expr *regexp.Regexp
+}
+
+func patterns(pairs ...string) []pattern {
+	list := make([]pattern, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		list = append(list, pattern{kind: pairs[i], expr: regexp.MustCompile(pairs[i+1])})
+	}
+	return list
+}
+
+var (
+	// goPatterns are used when a Go file does not parse.
+	goPatterns = patterns(
+		"method", `^func\s+\(\s*(?:\w+\s+)?\*?\s*(\w+)(?:\[[^\]]*\])?\s*\)\s*(\w+)`,
+		"func", `^func\s+(\w+)`,
+		"type", `^type\s+(\w+)`,
+	)
+	pythonPatterns = patterns(
+		"class", `^\s*class\s+(\w+)`,
+		"func", `^\s*(?:async\s+)?def\s+(\w+)`,
+	)
+	scriptPatterns = patterns(
+		"class", `^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`,
+		"func", `^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`,
+		"func", `^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s*)?(?:function\b|(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>)`,
+		"type", `^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+(\w+)`,
+	)
+	javaPatterns = patterns(
+		"class", `^\s*(?:(?:public|protected|private|static|final|abstract|sealed|data|open|internal)\s+)*(?:class|interface|enum|record|object)\s+(\w+)`,
+		"func", `^\s*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|override|open|internal|suspend)\s+)+(?:<[^>]*>\s*)?[\w<>\[\]?,.]+\s+(\w+)\s*\(`,
+		"func", `^\s*(?:(?:public|protected|private|override|open|internal|suspend|inline)\s+)*fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(\w+)\s*\(`,
+	)
+	rustPatterns = patterns(
+		"func", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+(\w+)`,
+		"type", `^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|union|type)\s+(\w+)`,
+	)
+	rubyPatterns = patterns(
+		"class", `^\s*class\s+([\w:]+)`,
+		"module", `^\s*module\s+([\w:]+)`,
+		"func", `^\s*def\s+(?:self\.)?([\w?!=]+)`,
+	)
+	phpPatterns = patterns(
+		"class", `^\s*(?:(?:abstract|final|readonly)\s+)*(?:class|interface|trait|enum)\s+(\w+)`,
+		"func", `^\s*(?:(?:public|protected|private|static|abstract|final)\s+)*function\s+&?\s*(\w+)`,
+	)
+)
+
+// languages maps file extensions to the patterns for their declarations.
+var languages = map[string][]pattern{
+	".py":   pythonPatterns,
+	".js":   scriptPatterns,
+	".jsx":  scriptPatterns,
+	".mjs":  scriptPatterns,
+	".cjs":  scriptPatterns,
+	".ts":   scriptPatterns,
+	".tsx":  scriptPatterns,
+	".java": javaPatterns,
+	".kt":   javaPatterns,
+	".rs":   rustPatterns,
+	".rb":   rubyPatterns,
+	".php":  phpPatterns,
+}
+
+// closingExpr matches lines that only close a block. They are left out of
+// declaration bodies, since where one declaration ends and the next begins
+// is only a guess.
+var closingExpr = regexp.MustCompile(`^\s*(?:[})\]][;,)]*|end)\s*$`)
+
+// matchDecls finds declarations line by line. Without a parser the end of
+// a declaration is not known, so each one runs until the next begins.
+func matchDecls(list []pattern, src string) []decl {
+	var (
+		decls []decl
+		body  []string
+	)
+	flush := func() {
+		if len(decls) > 0 {
+			decls[len(decls)-1].body = normalize(strings.Join(body, "\n"))
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(src, "\n") {
+		if kind, name, ok := matchLine(list, line); ok {
+			flush()
+			decls = append(decls, decl{kind: kind, name: name})
+		}
+		if !closingExpr.MatchString(line) {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return decls
+}
+
+func matchLine(list []pattern, line string) (string, string, bool) {
+	for _, p := range list {
+		m := p.expr.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var parts []string
+		for _, part := range m[1:] {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return p.kind, strings.Join(parts, "."), true
+	}
+	return "", "", false
+}
@@ -0,0 +1,120 @@
+// Package symbols works out which functions, methods and types a change
+// adds, removes or modifies by comparing the declarations in the old and new
+// versions of each file. Go is parsed with go/parser; other common languages
+// are matched line by line with regular expressions.
+package symbols
+
+import (
+	"path"
+	"strings"
+)
+
+// Status says what happened to a symbol.
+type Status string
+
+const (
+	Added    Status = "added"
+	Removed  Status = "removed"
+	Modified Status = "modified"
+)
+
+// Change is one symbol added, removed or modified in a file.
+type Change struct {
+	Path string
+	// Kind is the declaration keyword, such as func, method, type or class.
+	Kind string
+	// Name is the symbol's name; methods of Go types are "Type.Method".
+	Name   string
+	Status Status
+}
+
+func (c Change) String() string {
+	return string(c.Status) + " " + c.Kind + " " + c.Name
+}
+
+// decl is one declaration found in a file. body is its source text with
+// whitespace collapsed, so reformatting alone does not count as a change.
+type decl struct {
+	kind string
+	name string
+	body string
+}
+
+func (d decl) key() string {
+	return d.kind + " " + d.name
+}
+
+// Supported reports whether declarations can be extracted from filePath.
+func Supported(filePath string) bool {
+	ext := path.Ext(filePath)
+	return ext == ".go" || languages[ext] != nil
+}
+
+// Diff compares the declarations of the old and new content of filePath.
+// A nil side means the file does not exist there. Changes are listed in the
+// order the symbols appear in the new version, followed by removed ones.
+func Diff(filePath string, old, new []byte) []Change {
+	if !Supported(filePath) {
+		return nil
+	}
+	before := extract(filePath, old)
+	after := extract(filePath, new)
+
+	oldBodies := make(map[string]string, len(before))
+	for _, d := range before {
+		oldBodies[d.key()] = d.body
+	}
+	newKeys := make(map[string]bool, len(after))
+	var changes []Change
+	for _, d := range after {
+		newKeys[d.key()] = true
+		body, existed := oldBodies[d.key()]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Path: filePath, Kind: d.kind, Name: d.name, Status: Added})
+		case body != d.body:
+			changes = append(changes, Change{Path: filePath, Kind: d.kind, Name: d.name, Status: Modified})
+		}
+	}
+	for _, d := range before {
+		if !newKeys[d.key()] {
+			changes = append(changes, Change{Path: filePath, Kind: d.kind, Name: d.name, Status: Removed})
+		}
+	}
+	return changes
+}
+
+// extract returns the declarations in src, merging declarations that share
+// a kind and name, such as overloads, into one.
+func extract(filePath string, src []byte) []decl {
+	if src == nil {
+		return nil
+	}
+	var decls []decl
+	if path.Ext(filePath) == ".go" {
+		var err error
+		if decls, err = goDecls(src); err != nil {
+			// A half-edited file may not parse; the patterns still find
+			// most declarations.
+			decls = matchDecls(goPatterns, string(src))
+		}
+	} else {
+		decls = matchDecls(languages[path.Ext(filePath)], string(src))
+	}
+
+	merged := make([]decl, 0, len(decls))
+	index := make(map[string]int, len(decls))
+	for _, d := range decls {
+		if i, ok := index[d.key()]; ok {
+			merged[i].body += "\n" + d.body
+			continue
+		}
+		index[d.key()] = len(merged)
+		merged = append(merged, d)
+	}
+	return merged
+}
+
+func normalize(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
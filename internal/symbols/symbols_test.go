@@ -0,0 +1,124 @@
+package symbols
+
+import (
+	"strings"
+	"testing"
+)
+
+func changeList(changes []Change) string {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDiffGoDeclarations(t *testing.T) {
+	old := `package p
+
+const Limit = 10
+
+const internal = 1
+
+type Parser struct{ n int }
+
+func (p *Parser) Parse() int { return p.n }
+
+func helper() {}
+
+func Old() {}
+`
+	new := `package p
+
+const Limit = 20
+
+const internal = 2
+
+type Parser struct{ n int }
+
+// Parse now doubles.
+func (p *Parser) Parse() int {
+	return p.n * 2
+}
+
+func helper()   {}
+
+func (s Set[T]) Add(v T) {}
+
+func New() {}
+`
+	got := changeList(Diff("p/p.go", []byte(old), []byte(new)))
+	want := strings.Join([]string{
+		"modified const Limit",
+		"modified method Parser.Parse",
+		"added method Set.Add",
+		"added func New",
+		"removed func Old",
+	}, "\n")
+	if got != want {
+		t.Fatalf("Diff() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiffGoFallsBackWhenTheFileDoesNotParse(t *testing.T) {
+	old := "package p\n\nfunc A() {}\n"
+	new := "package p\n\nfunc A() {\n\tif {\n}\n\nfunc (r *Repo) B() {}\n"
+	got := changeList(Diff("p.go", []byte(old), []byte(new)))
+	if got != "modified func A\nadded method Repo.B" {
+		t.Fatalf("Diff() =\n%s", got)
+	}
+}
+
+func TestDiffNewAndDeletedFiles(t *testing.T) {
+	src := []byte("package p\n\ntype T int\n")
+	if got := changeList(Diff("t.go", nil, src)); got != "added type T" {
+		t.Fatalf("Diff(new file) = %q", got)
+	}
+	if got := changeList(Diff("t.go", src, nil)); got != "removed type T" {
+		t.Fatalf("Diff(deleted file) = %q", got)
+	}
+	if got := Diff("notes.txt", nil, []byte("def x():\n")); got != nil {
+		t.Fatalf("unsupported files should have no symbols, got %v", got)
+	}
+}
+
+func TestDiffWithPatterns(t *testing.T) {
+	for _, tc := range []struct {
+		path, old, new, want string
+	}{
+		{
+			path: "app.py",
+			old:  "class Cart:\n    def total(self):\n        return 1\n\n    def clear(self):\n        pass\n",
+			new:  "class Cart:\n    def total(self):\n        return 2\n\n    def clear(self):\n        pass\n\nasync def fetch():\n    pass\n",
+			want: "modified func total\nadded func fetch",
+		},
+		{
+			path: "src/api.ts",
+			old:  "export function get() {}\nexport const post = async (url: string) => {}\n",
+			new:  "export function get() { return 1 }\nexport interface Options {}\nexport default class Client {}\n",
+			want: "modified func get\nadded type Options\nadded class Client\nremoved func post",
+		},
+		{
+			path: "src/lib.rs",
+			old:  "pub fn parse() {}\n",
+			new:  "pub(crate) async fn parse() {}\npub struct Token;\n",
+			want: "modified func parse\nadded type Token",
+		},
+		{
+			path: "Service.java",
+			old:  "public class Service {\n    public void start() {}\n}\n",
+			new:  "public class Service {\n    public void start() {}\n    private static List<String> names(int n) {}\n}\n",
+			want: "added func names",
+		},
+		{
+			path: "lib/user.rb",
+			old:  "module Auth\n  class User\n    def admin?\n    end\n  end\nend\n",
+			new:  "module Auth\n  class User\n    def admin?\n      true\n    end\n  end\nend\n",
+			want: "modified func admin?",
+		},
+	} {
+		if got := changeList(Diff(tc.path, []byte(tc.old), []byte(tc.new))); got != tc.want {
+			t.Fatalf("Diff(%s) =\n%s\nwant\n%s", tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package ticket finds ticket IDs in branch names and adds them to commit
+// messages.
+package ticket
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Placeholder is replaced by the ticket ID in a template.
+	Placeholder = "{ticket}"
+	// SubjectPlaceholder marks a template that rewrites the subject line;
+	// templates without it are added as footer lines.
+	SubjectPlaceholder = "{subject}"
+	// DefaultTemplate adds a Refs trailer.
+	DefaultTemplate = "Refs: {ticket}"
+)
+
+var trailerLineExpr = regexp.MustCompile(`^[A-Za-z0-9-]+: \S|^Closes #|^Fixes #`)
+
+// Rules extract ticket IDs from branch names and place them in messages.
+type Rules struct {
+	patterns []*regexp.Regexp
+	template string
+}
+
+// Compile checks patterns and template. Each pattern's first capture group
+// is the ticket ID, or the whole match when it has none. An empty template
+// means DefaultTemplate.
+func Compile(patterns []string, template string) (*Rules, error) {
+	rules := &Rules{template: template}
+	if rules.template == "" {
+		rules.template = DefaultTemplate
+	}
+	if !strings.Contains(rules.template, Placeholder) {
+		return nil, fmt.Errorf("ticket template %q does not contain %s", rules.template, Placeholder)
+	}
+	if strings.Contains(rules.template, "\n") {
+		return nil, fmt.Errorf("ticket template %q must be a single line", rules.template)
+	}
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		expr, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern %q: %w", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, expr)
+	}
+	return rules, nil
+}
+
+// Empty reports whether no patterns are configured.
+func (r *Rules) Empty() bool {
+	return r == nil || len(r.patterns) == 0
+}
+
+// Extract returns the ticket IDs found in branch, in order, without
+// duplicates.
+func (r *Rules) Extract(branch string) []string {
+	if r.Empty() {
+		return nil
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, expr := range r.patterns {
+		for _, m := range expr.FindAllStringSubmatch(branch, -1) {
+			id := m[0]
+			if len(m) > 1 && m[1] != "" {
+				id = m[1]
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// Apply adds ids to message following the template. A subject template
+// ("{ticket}: {subject}") rewrites the subject once with all IDs joined by
+// ", "; any other template becomes one footer line per ID, kept together with
+// existing trailers. IDs already mentioned in the message are not added again.
+func (r *Rules) Apply(message string, ids []string) string {
+	missing := Missing(message, ids)
+	if len(missing) == 0 {
+		return message
+	}
+	if strings.Contains(r.template, SubjectPlaceholder) {
+		subject, rest, hasRest := strings.Cut(message, "\n")
+		subject = strings.NewReplacer(
+			Placeholder, strings.Join(missing, ", "),
+			SubjectPlaceholder, strings.TrimSpace(subject),
+		).Replace(r.template)
+		if !hasRest {
+			return subject
+		}
+		return subject + "\n" + rest
+	}
+
+	lines := make([]string, len(missing))
+	for i, id := range missing {
+		lines[i] = strings.ReplaceAll(r.template, Placeholder, id)
+	}
+	message = strings.TrimRight(message, "\n")
+	if endsWithTrailers(message) {
+		return message + "\n" + strings.Join(lines, "\n")
+	}
+	return message + "\n\n" + strings.Join(lines, "\n")
+}
+
+// Missing returns the ids that do not appear in message.
+func Missing(message string, ids []string) []string {
+	var missing []string
+	for _, id := range ids {
+		if !containsID(message, id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// containsID matches id as a whole word, so PAY-12 is not found in PAY-123.
+func containsID(message, id string) bool {
+	expr := regexp.MustCompile(`(^|[^A-Za-z0-9])` + regexp.QuoteMeta(id) + `($|[^A-Za-z0-9])`)
+	return expr.MatchString(message)
+}
+
+// endsWithTrailers reports whether the last paragraph of a message with a
+// body consists only of trailer-like lines.
+func endsWithTrailers(message string) bool {
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) < 2 {
+		return false
+	}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if !trailerLineExpr.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package ticket
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractUsesCaptureGroupAndDedupes(t *testing.T) {
+	rules, err := Compile([]string{`[A-Z][A-Z0-9]+-\d+`, `^(?:\w+/)?(\d+)-`}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rules.Extract("feature/PAY-1234-refund-flow-PAY-1234"); !reflect.DeepEqual(got, []string{"PAY-1234"}) {
+		t.Fatalf("Extract() = %v", got)
+	}
+	if got := rules.Extract("fix/42-crash"); !reflect.DeepEqual(got, []string{"42"}) {
+		t.Fatalf("Extract() = %v", got)
+	}
+	if got := rules.Extract("main"); got != nil {
+		t.Fatalf("Extract(main) = %v", got)
+	}
+}
+
+func TestCompileRejectsBadConfig(t *testing.T) {
+	if _, err := Compile([]string{"("}, ""); err == nil {
+		t.Fatal("expected invalid pattern error")
+	}
+	if _, err := Compile(nil, "Refs: PAY"); err == nil {
+		t.Fatal("expected template without placeholder to be rejected")
+	}
+	if rules, err := Compile([]string{" "}, ""); err != nil || !rules.Empty() {
+		t.Fatalf("blank patterns should be ignored, got %v", err)
+	}
+}
+
+func TestApplyTemplates(t *testing.T) {
+	cases := []struct {
+		template, message string
+		ids               []string
+		want              string
+	}{
+		{"", "Add refunds\n\nExplain.", []string{"PAY-1"}, "Add refunds\n\nExplain.\n\nRefs: PAY-1"},
+		{"", "Add refunds", []string{"PAY-1"}, "Add refunds\n\nRefs: PAY-1"},
+		{"", "Add refunds\n\nExplain.\n\nSigned-off-by: A <a@b>", []string{"PAY-1"}, "Add refunds\n\nExplain.\n\nSigned-off-by: A <a@b>\nRefs: PAY-1"},
+		{"Closes #{ticket}", "Fix crash\n", []string{"4", "5"}, "Fix crash\n\nCloses #4\nCloses #5"},
+		{"{ticket}: {subject}", "Add refunds\n\nExplain.", []string{"PAY-1", "PAY-2"}, "PAY-1, PAY-2: Add refunds\n\nExplain."},
+		{"[{ticket}] {subject}", "Add refunds", []string{"PAY-1"}, "[PAY-1] Add refunds"},
+		{"{ticket}: {subject}", "PAY-1: Add refunds", []string{"PAY-1"}, "PAY-1: Add refunds"},
+		{"", "Add refunds for PAY-12", []string{"PAY-1"}, "Add refunds for PAY-12\n\nRefs: PAY-1"},
+	}
+	for _, tc := range cases {
+		rules, err := Compile([]string{"x"}, tc.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := rules.Apply(tc.message, tc.ids)
+		if got != tc.want {
+			t.Errorf("Apply(%q, %q) with %q =\n%q\nwant\n%q", tc.message, tc.ids, tc.template, got, tc.want)
+		}
+		if missing := Missing(got, tc.ids); len(missing) > 0 {
+			t.Errorf("Missing() = %v after Apply", missing)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package trailer reads and merges the "Key: value" trailer lines at the end
+// of commit messages, following `git interpret-trailers`.
+package trailer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// CoAuthoredBy credits a pair-programming partner.
+	CoAuthoredBy = "Co-authored-by"
+	// NamePlaceholder and EmailPlaceholder are replaced by the committer's
+	// identity in configured trailers.
+	NamePlaceholder  = "{name}"
+	EmailPlaceholder = "{email}"
+)
+
+var (
+	keyExpr   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+	identExpr = regexp.MustCompile(`^([^<>]+?)\s*<([^<>\s]+@[^<>\s]+)>$`)
+)
+
+// cherryPickPrefix starts the line git adds for `cherry-pick -x`, which git
+// counts as part of the trailer block.
+const cherryPickPrefix = "(cherry picked from commit "
+
+// Trailer is one "Key: value" line.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// Parse reads a "Key: value" line.
+func Parse(line string) (Trailer, bool) {
+	key, value, ok := strings.Cut(line, ":")
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if !ok || !keyExpr.MatchString(key) || value == "" {
+		return Trailer{}, false
+	}
+	return Trailer{Key: key, Value: value}, true
+}
+
+// ParseIdent splits "Name <email>".
+func ParseIdent(ident string) (name, email string, ok bool) {
+	m := identExpr.FindStringSubmatch(strings.TrimSpace(ident))
+	if m == nil {
+		return "", "", false
+	}
+	return strings.TrimSpace(m[1]), m[2], true
+}
+
+// Compile checks configured "Key: value" trailers and fills in the
+// committer's name and email.
+func Compile(templates []string, name, email string) ([]Trailer, error) {
+	replacer := strings.NewReplacer(NamePlaceholder, name, EmailPlaceholder, email)
+	var trailers []Trailer
+	for _, template := range templates {
+		if strings.TrimSpace(template) == "" {
+			continue
+		}
+		if strings.Contains(template, "\n") {
+			return nil, fmt.Errorf("trailer %q must be a single line", template)
+		}
+		t, ok := Parse(replacer.Replace(template))
+		if !ok {
+			return nil, fmt.Errorf("trailer %q is not in \"Key: value\" form", template)
+		}
+		trailers = append(trailers, t)
+	}
+	return trailers, nil
+}
+
+// Trailers returns the trailers at the end of message.
+func Trailers(message string) []Trailer {
+	_, block := split(message)
+	var trailers []Trailer
+	for _, line := range block {
+		if t, ok := Parse(line); ok {
+			trailers = append(trailers, t)
+		}
+	}
+	return trailers
+}
+
+// Merge appends add to the trailer block of message, starting one when the
+// message has none. Like `git interpret-trailers --if-exists addIfDifferent`,
+// a trailer is skipped when one with the same key (ignoring case) and value
+// is already present; for values holding an email address the addresses
+// are compared.
+func Merge(message string, add []Trailer) string {
+	text, block := split(message)
+	var existing []Trailer
+	for _, line := range block {
+		if t, ok := Parse(line); ok {
+			existing = append(existing, t)
+		}
+	}
+	var lines []string
+	for _, t := range add {
+		if contains(existing, t) {
+			continue
+		}
+		existing = append(existing, t)
+		lines = append(lines, t.String())
+	}
+	if len(lines) == 0 {
+		return message
+	}
+	block = append(block, lines...)
+	return text + "\n\n" + strings.Join(block, "\n")
+}
+
+// split separates message into the text before its trailer block and the
+// lines of the block. The block is the last paragraph when every line in
+// it is a trailer, a continuation of one, or git's cherry-pick line, and the
+// paragraph is not the subject.
+func split(message string) (string, []string) {
+	message = strings.TrimRight(message, "\n")
+	idx := strings.LastIndex(message, "\n\n")
+	if idx < 0 {
+		return message, nil
+	}
+	lines := strings.Split(message[idx+2:], "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, cherryPickPrefix):
+		case i > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+		default:
+			if _, ok := Parse(line); !ok {
+				return message, nil
+			}
+		}
+	}
+	return strings.TrimRight(message[:idx], "\n"), lines
+}
+
+func contains(trailers []Trailer, want Trailer) bool {
+	for _, t := range trailers {
+		if strings.EqualFold(t.Key, want.Key) && sameValue(t.Value, want.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameValue(a, b string) bool {
+	if strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ") {
+		return true
+	}
+	_, emailA, okA := ParseIdent(a)
+	_, emailB, okB := ParseIdent(b)
+	return okA && okB && strings.EqualFold(emailA, emailB)
+}
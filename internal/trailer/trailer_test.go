@@ -0,0 +1,64 @@
+package trailer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeAppendsToExistingBlockWithoutDuplicates(t *testing.T) {
+	message := "Add parser\n\nExplain the change.\n\nRefs: PAY-1\nCo-authored-by: jane <JANE@example.com>"
+	got := Merge(message, []Trailer{
+		{Key: "co-authored-by", Value: "Jane Doe <jane@example.com>"},
+		{Key: CoAuthoredBy, Value: "Bob <bob@example.com>"},
+		{Key: "Signed-off-by", Value: "Me <me@example.com>"},
+		{Key: CoAuthoredBy, Value: "Bob <bob@example.com>"},
+	})
+	want := "Add parser\n\nExplain the change.\n\nRefs: PAY-1\nCo-authored-by: jane <JANE@example.com>\nCo-authored-by: Bob <bob@example.com>\nSigned-off-by: Me <me@example.com>"
+	if got != want {
+		t.Fatalf("Merge() =\n%s\nwant\n%s", got, want)
+	}
+	if again := Merge(got, Trailers(got)); again != got {
+		t.Fatalf("merging a message's own trailers should change nothing, got\n%s", again)
+	}
+}
+
+func TestMergeStartsBlockAfterBody(t *testing.T) {
+	add := []Trailer{{Key: "Signed-off-by", Value: "Me <me@example.com>"}}
+	for message, want := range map[string]string{
+		"Fix: handle empty input":                                           "Fix: handle empty input\n\nSigned-off-by: Me <me@example.com>",
+		"Fix lexer\n\nThe lexer now skips BOMs.\n":                          "Fix lexer\n\nThe lexer now skips BOMs.\n\nSigned-off-by: Me <me@example.com>",
+		"Pick fix\n\nBody.\n\n(cherry picked from commit 0123456789abcdef)": "Pick fix\n\nBody.\n\n(cherry picked from commit 0123456789abcdef)\nSigned-off-by: Me <me@example.com>",
+	} {
+		if got := Merge(message, add); got != want {
+			t.Fatalf("Merge(%q) =\n%s\nwant\n%s", message, got, want)
+		}
+	}
+}
+
+func TestTrailersAndContinuationLines(t *testing.T) {
+	message := "Subject\n\nBody with a colon: not a block\nbecause this line is prose.\n\nReviewed-by: A <a@example.com>\nNote: wrapped\n  over two lines"
+	got := Trailers(message)
+	want := []Trailer{{Key: "Reviewed-by", Value: "A <a@example.com>"}, {Key: "Note", Value: "wrapped"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Trailers() = %+v, want %+v", got, want)
+	}
+	if got := Trailers("Subject\n\nBody with a colon: not a block\nbecause this line is prose."); got != nil {
+		t.Fatalf("a prose paragraph is not a trailer block, got %+v", got)
+	}
+}
+
+func TestCompileAndParseIdent(t *testing.T) {
+	got, err := Compile([]string{"Signed-off-by: {name} <{email}>", " "}, "Me", "me@example.com")
+	if err != nil || !reflect.DeepEqual(got, []Trailer{{Key: "Signed-off-by", Value: "Me <me@example.com>"}}) {
+		t.Fatalf("Compile() = %+v, %v", got, err)
+	}
+	if _, err := Compile([]string{"not a trailer"}, "", ""); err == nil {
+		t.Fatal("expected an error for a line without a key")
+	}
+	if name, email, ok := ParseIdent(" Jane Doe <jane@example.com> "); !ok || name != "Jane Doe" || email != "jane@example.com" {
+		t.Fatalf("ParseIdent() = %q, %q, %v", name, email, ok)
+	}
+	if _, _, ok := ParseIdent("jane@example.com"); ok {
+		t.Fatal("an address without a name is not an ident")
+	}
+}
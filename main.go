@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -13,6 +15,8 @@ import (
 	"github.com/ktappdev/gitcomm/internal/config"
 	"github.com/ktappdev/gitcomm/internal/diag"
 	"github.com/ktappdev/gitcomm/internal/git"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+	"github.com/ktappdev/gitcomm/internal/ignore"
 )
 
 const updateModule = "github.com/ktappdev/gitcomm@latest"
@@ -20,11 +24,21 @@ const updateModule = "github.com/ktappdev/gitcomm@latest"
 var (
 	debug       = false
 	execCommand = exec.Command
+	stdin       = bufio.NewReader(os.Stdin)
+	// repo is the repository gitcomm works on; -C changes it.
+	repo = git.Default()
 )
 
+func readLine() string {
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+// logf prints debug output. It goes to stderr so it never mixes with a
+// result piped from stdout.
 func logf(format string, args ...any) {
 	if debug {
-		fmt.Printf(format+"\n", args...)
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
 	}
 }
 
@@ -34,6 +48,15 @@ func main() {
 	autoFlag := flag.Bool("auto", false, "Automatically commit with the generated message")
 	autoPushFlag := flag.Bool("ap", false, "Automatically commit and push with the generated message")
 	stageAllFlag := flag.Bool("sa", false, "Stage all changes before analyzing")
+	allFlag := flag.Bool("a", false, "Include modified and deleted tracked files, like git commit -a")
+	workTreeFlag := flag.Bool("wt", false, "Preview a message for the unstaged work-tree changes without touching the index")
+	amendFlag := flag.Bool("amend", false, "Regenerate the message for HEAD from HEAD^..index and amend it")
+	editFlag := flag.Bool("e", false, "Review the generated message in git's editor, then commit")
+	remoteFlag := flag.String("remote", "", "Remote to push to with -ap (default: the upstream, then origin)")
+	commitFlags := registerCommitFlags(flag.CommandLine)
+	diffFlags := registerDiffFlags(flag.CommandLine)
+	diffFileFlag := flag.String("diff-file", "", "Print a message for the diff in `path` instead of the staged changes (- for stdin)")
+	dirFlag := flag.String("C", "", "Run as if gitcomm was started in `path`")
 	debugFlag := flag.Bool("debug", false, "Enable verbose debug logging")
 	setModelFlag := flag.String("set-model", "", "Set model at position (format: position:provider/model-name)")
 	flag.Parse()
@@ -44,9 +67,37 @@ func main() {
 	} else if debug {
 		fmt.Printf("warning: failed to initialize diagnostics logging: %v\n", err)
 	}
-	logf("startup: flags setup=%v auto=%v ap=%v sa=%v debug=%v", *setupFlag, *autoFlag, *autoPushFlag, *stageAllFlag, *debugFlag)
-
-	if flag.NArg() > 0 {
+	if *dirFlag != "" {
+		opened, err := git.Open(*dirFlag)
+		if err != nil {
+			diag.Error("main", "failed to open repository", "dir", *dirFlag, "error", err)
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		repo = opened
+		git.SetDefault(repo)
+	}
+	if err := diffFlags.validate(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	cliDiffFlags = diffFlags
+	logf("startup: flags setup=%v auto=%v ap=%v sa=%v amend=%v e=%v debug=%v", *setupFlag, *autoFlag, *autoPushFlag, *stageAllFlag, *amendFlag, *editFlag, *debugFlag)
+
+	paths, dashDash := pathspecs(os.Args[1:], flag.Args())
+	if readsDiffInput(*diffFileFlag, flag.Args(), dashDash) {
+		err := checkDiffInput(*diffFileFlag, flag.Args(), dashDash, setFlags())
+		if err == nil {
+			err = runDiffInput(*diffFileFlag)
+		}
+		if err != nil {
+			diag.Error("main", "message for diff input failed", "error", err)
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() > 0 && !dashDash {
 		switch flag.Arg(0) {
 		case "update":
 			if err := runSelfUpdate(); err != nil {
@@ -60,6 +111,50 @@ func main() {
 			fmt.Println("✅ GitComm updated successfully.")
 			fmt.Println("   This updates Go-installed copies of GitComm.")
 			return
+		case "pr":
+			if err := runPR(flag.Args()[1:]); err != nil {
+				diag.Error("main", "pr failed", "error", err)
+				fmt.Fprintf(os.Stderr, "❌ Pull request generation failed: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "changelog":
+			if err := runChangelog(flag.Args()[1:]); err != nil {
+				diag.Error("main", "changelog failed", "error", err)
+				fmt.Fprintf(os.Stderr, "❌ Changelog generation failed: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "split":
+			if err := runSplit(flag.Args()[1:]); err != nil && !errors.Is(err, errCommitReported) {
+				diag.Error("main", "split failed", "error", err)
+				fmt.Printf("❌ Split failed: %v\n", err)
+			}
+			return
+		case "hook":
+			if err := runHookCommand(flag.Args()[1:]); err != nil {
+				diag.Error("main", "hook command failed", "error", err)
+				fmt.Printf("❌ Hook command failed: %v\n", err)
+			}
+			return
+		case "retry":
+			if err := runRetry(flag.Args()[1:]); err != nil && !errors.Is(err, errCommitReported) {
+				diag.Error("main", "retry failed", "error", err)
+				fmt.Printf("❌ Retry failed: %v\n", err)
+			}
+			return
+		case "pair":
+			if err := runPair(flag.Args()[1:]); err != nil {
+				diag.Error("main", "pair command failed", "error", err)
+				fmt.Printf("❌ Pair command failed: %v\n", err)
+			}
+			return
+		case "reword":
+			if err := runReword(flag.Args()[1:]); err != nil {
+				diag.Error("main", "reword failed", "error", err)
+				fmt.Printf("❌ Reword failed: %v\n", err)
+			}
+			return
 		default:
 			fmt.Printf("❌ Unknown command: %s\n", flag.Arg(0))
 			printHelp()
@@ -84,10 +179,49 @@ func main() {
 		return
 	}
 
+	if *remoteFlag != "" && !*autoPushFlag {
+		fmt.Println("❌ -remote only applies when pushing; add -ap.")
+		return
+	}
+
+	commitOptions, err := commitFlags.options(*amendFlag, *editFlag)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	committing := *autoFlag || *autoPushFlag || *amendFlag || *editFlag
+	if !committing && commitFlags.used() {
+		fmt.Println("❌ -S, -signoff, -no-verify, -author and -commit-flag only apply when committing; add -auto, -ap, -e or -amend.")
+		return
+	}
+	scope := git.Scope{Paths: paths, All: *allFlag, WorkTree: *workTreeFlag}
+	if msg := checkScope(scope, dashDash, *stageAllFlag, committing); msg != "" {
+		fmt.Println("❌ " + msg)
+		return
+	}
+
+	operation := git.InProgress()
+	if operation != nil && !checkOperation(operation, *amendFlag, *autoPushFlag) {
+		return
+	}
+	if operation != nil && len(paths) > 0 && operation.Kind != git.OpRebase {
+		fmt.Printf("❌ A %s is in progress, so only the whole staged result can be committed; drop the paths.\n", operation.Kind)
+		return
+	}
+
+	diffOptions := loadDiffOptions()
+	if *amendFlag {
+		base, ok := prepareAmend()
+		if !ok {
+			return
+		}
+		diffOptions.Base = base
+	}
+
 	if *stageAllFlag {
 		fmt.Println("📁 Staging all changes...")
 		logf("git.StageAll: invoking")
-		if err := git.StageAll(); err != nil {
+		if err := repo.StageAll(); err != nil {
 			if strings.Contains(err.Error(), "not a git repository") {
 				fmt.Println("❌ This directory is not a Git repository.")
 				fmt.Println("   Run `git init` to create one, or run gitcomm inside an existing repo.")
@@ -100,8 +234,26 @@ func main() {
 		fmt.Println("✅ All changes staged successfully!")
 	}
 
+	// -a, -wt and paths are collected in a temporary index, which is also
+	// what gets committed, so the commit matches what was analyzed.
+	work := repo
+	var scoped *git.ScopedIndex
+	if !scope.Empty() {
+		scoped, err = repo.PrepareScope(scope)
+		if err != nil {
+			diag.Error("main", "failed to prepare scoped changes", "error", err)
+			fmt.Printf("❌ Error collecting the changes: %v\n", err)
+			return
+		}
+		defer scoped.Close()
+		work = scoped.Repo
+		if scoped.Base != "" {
+			diffOptions.Base = scoped.Base
+		}
+	}
+
 	logf("git.GetStagedChanges: fetching staged diff")
-	diff, err := git.GetStagedChanges()
+	changes, err := work.GetStagedChanges(diffOptions)
 	if err != nil {
 		diag.Error("main", "failed to get staged changes", "error", err)
 		if strings.Contains(err.Error(), "not a git repository") {
@@ -113,17 +265,37 @@ func main() {
 		printHelp()
 		return
 	}
-	logf("git.GetStagedChanges: got %d bytes", len(diff))
+	logf("git.GetStagedChanges: got %d bytes, %d files", len(changes.Diff), len(changes.Files))
 
-	if diff == "" {
+	if changes.Diff == "" && *amendFlag {
+		diag.Warn("main", "amend has no changes against base", "base", diffOptions.Base)
+		fmt.Println("⚠️  HEAD and the index introduce no changes, so there is nothing to describe.")
+		return
+	}
+	if changes.Diff == "" {
 		diag.Warn("main", "no staged changes found")
-		fmt.Println("⚠️  No staged changes. Please stage your changes before running gitcomm.")
-		printHelp()
+		fmt.Println(noChangesMessage(scope))
+		if scope.Empty() {
+			printHelp()
+		}
 		return
 	}
 
 	logf("analyzer.AnalyzeChanges: begin")
-	commitMessage, err := analyzer.AnalyzeChanges(diff)
+	// When amending, HEAD itself is being replaced; a root commit has no
+	// history to learn from.
+	historyRev := "HEAD"
+	if *amendFlag && diffOptions.Base != "HEAD^" {
+		historyRev = ""
+	} else if *amendFlag {
+		historyRev = "HEAD^"
+	}
+	history, examples := loadStyleHistory(historyRev, changes.Files)
+	input := analyzer.Input{Diff: changes.Diff, Files: changes.Files, History: history, StyleExamples: examples, Submodules: changes.Submodules, Symbols: changes.Symbols}
+	if operation != nil {
+		input.Operation = &analyzer.Operation{Kind: operation.Kind, Message: operation.Message, Conflicts: operation.Conflicts}
+	}
+	commitMessage, err := analyzer.AnalyzeChanges(input)
 	if err != nil {
 		diag.Error("main", "analysis failed", "error", err)
 		fmt.Printf("❌ Error analyzing changes: %v\n", err)
@@ -134,40 +306,232 @@ func main() {
 		return
 	}
 	logf("analyzer.AnalyzeChanges: result length=%d", len(commitMessage))
+	commitMessage, err = addTickets(commitMessage)
+	if err != nil {
+		diag.Error("main", "ticket check failed", "error", err)
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	commitMessage, err = addTrailers(commitMessage)
+	if err != nil {
+		diag.Error("main", "failed to add trailers", "error", err)
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
 
 	fmt.Println("\n📝 Generated Commit Message:")
 	fmt.Println("┌" + strings.Repeat("─", 50))
 	fmt.Println(commitMessage)
 	fmt.Println("└" + strings.Repeat("─", 50))
 
-	if *autoFlag || *autoPushFlag {
+	if scope.WorkTree {
+		fmt.Println("\n👀 Preview only: nothing was staged or committed.")
+		return
+	}
+
+	if *amendFlag {
+		if commitMessage == "" {
+			fmt.Println("❌ Error: Could not extract a commit message from the analysis.")
+			return
+		}
+		fmt.Println("\n✏️  Amending HEAD with the regenerated message...")
+		logf("git.Commit: amending")
+		if err := commitScoped(scoped, commitMessage, commitOptions); err != nil {
+			reportCommitFailure(err, commitMessage, commitOptions, scope, *autoPushFlag, *remoteFlag)
+			return
+		}
+		fmt.Println("✅ HEAD amended successfully!")
+		if *autoPushFlag {
+			pushAfterCommit(*remoteFlag, true)
+		}
+		return
+	}
+
+	if *autoFlag || *autoPushFlag || *editFlag {
 		if commitMessage == "" {
 			fmt.Println("❌ Error: Could not extract a commit message from the analysis.")
 			printHelp()
 			return
 		}
-		fmt.Println("\n💾 Auto-committing with the generated message...")
+		if *editFlag {
+			fmt.Println("\n📝 Opening the generated message in your editor...")
+		} else {
+			fmt.Println("\n💾 Auto-committing with the generated message...")
+		}
 		logf("git.Commit: committing")
-		err = git.Commit(commitMessage)
+		err = commitScoped(scoped, commitMessage, commitOptions)
 		if err != nil {
-			fmt.Printf("❌ Error committing: %v\n", err)
-			printHelp()
+			reportCommitFailure(err, commitMessage, commitOptions, scope, *autoPushFlag, *remoteFlag)
 			return
 		}
 		fmt.Println("✅ Changes committed successfully!")
 
 		if *autoPushFlag {
-			fmt.Println("🚀 Pushing changes to remote repository...")
-			logf("git.Push: pushing")
-			err = git.Push()
-			if err != nil {
-				fmt.Printf("❌ Error pushing changes: %v\n", err)
-				printHelp()
-				return
+			pushAfterCommit(*remoteFlag, false)
+		}
+	}
+}
+
+// checkOperation reports on an unfinished merge, rebase, cherry-pick or
+// revert and refuses combinations that would go wrong: committing with
+// unresolved conflicts, amending in the middle of a merge, cherry-pick or
+// revert, and pushing a branch that is being rebased.
+func checkOperation(op *git.Operation, amend, push bool) bool {
+	diag.Info("main", "operation in progress", "kind", op.Kind, "conflicts", len(op.Conflicts))
+	if push && op.Kind == git.OpRebase {
+		fmt.Println("❌ A rebase is in progress. Finish it with `git rebase --continue` (or abort it) before pushing; -ap is not allowed until then.")
+		return false
+	}
+	if amend && op.Kind != git.OpRebase {
+		fmt.Printf("❌ A %s is in progress, so HEAD cannot be amended. Commit it first, or run `git %s --abort`.\n", op.Kind, op.Kind)
+		return false
+	}
+	unmerged, err := git.UnmergedPaths()
+	if err != nil {
+		diag.Warn("main", "failed to list unmerged paths", "error", err)
+	}
+	if len(unmerged) > 0 {
+		fmt.Printf("❌ A %s is in progress and these files still have conflicts: %s\n", op.Kind, strings.Join(unmerged, ", "))
+		fmt.Println("   Resolve them and stage the result with `git add`, then run gitcomm again.")
+		return false
+	}
+	switch op.Kind {
+	case git.OpMerge:
+		fmt.Println("🔀 A merge is in progress; the message will describe the merge.")
+	case git.OpRebase:
+		fmt.Println("🔁 A rebase is in progress; the replayed commit's message is used as context.")
+	case git.OpCherryPick:
+		fmt.Println("🍒 A cherry-pick is in progress; the original message is used as context and any \"cherry picked from\" line is kept.")
+	case git.OpRevert:
+		fmt.Println("↩️  A revert is in progress; git's \"This reverts commit\" line is kept.")
+	}
+	return true
+}
+
+func pushAfterCommit(remote string, amended bool) {
+	fmt.Println("🚀 Pushing changes to remote repository...")
+	logf("pushChanges: remote=%q amended=%v", remote, amended)
+	if err := pushChanges(remote, amended); err != nil {
+		diag.Error("main", "push failed", "error", err)
+		fmt.Printf("❌ Error pushing changes: %v\n", err)
+		return
+	}
+	fmt.Println("✅ Changes pushed successfully!")
+}
+
+// prepareAmend checks that HEAD can be amended, warns before rewriting a
+// commit that is already on a remote, and returns the diff base for HEAD.
+func prepareAmend() (string, bool) {
+	if !git.HasHead() {
+		fmt.Println("❌ There is no commit to amend yet. Run gitcomm without -amend for the first commit.")
+		return "", false
+	}
+	if remoteRef, err := git.PublishedIn("HEAD"); err != nil {
+		diag.Warn("main", "could not check whether HEAD is published", "error", err)
+	} else if remoteRef != "" {
+		fmt.Printf("⚠️  HEAD has already been pushed to %s. Amending rewrites published history.\n", remoteRef)
+		fmt.Print("Amend anyway? (y/N): ")
+		choice := strings.ToLower(strings.TrimSpace(readLine()))
+		if choice != "y" && choice != "yes" {
+			fmt.Println("Amend cancelled.")
+			return "", false
+		}
+	}
+
+	base, err := git.AmendBase()
+	if err != nil {
+		fmt.Printf("❌ Error finding the parent of HEAD: %v\n", err)
+		return "", false
+	}
+	diag.Info("main", "amend mode", "base", base)
+	return base, true
+}
+
+// styleHistorySize is how many recent commits are sampled to learn the
+// repository's commit style.
+const styleHistorySize = 30
+
+// maxStylePaths limits the pathspec used to find related commits.
+const maxStylePaths = 50
+
+// loadStyleHistory samples the commit messages the repository's style is
+// learned from: commits up to rev that touch the same files first, topped up
+// with the most recent ones. It also returns how many to quote as examples.
+// Nothing is returned when rev is empty or style learning is disabled.
+func loadStyleHistory(rev string, files []gitdiff.FileStat) ([]string, int) {
+	cfg, _ := config.LoadRuntimeConfig()
+	if rev == "" || cfg.DisableStyleLearning {
+		return nil, 0
+	}
+	if _, err := git.ResolveCommit(rev); err != nil {
+		return nil, 0
+	}
+
+	var history []string
+	seen := make(map[string]bool)
+	add := func(messages []string) {
+		for _, message := range messages {
+			if len(history) < styleHistorySize && !seen[message] {
+				seen[message] = true
+				history = append(history, message)
+			}
+		}
+	}
+	if len(files) > 0 && len(files) <= maxStylePaths {
+		var paths []string
+		for _, file := range files {
+			paths = append(paths, file.Path)
+			if file.OldPath != "" && file.OldPath != file.Path {
+				paths = append(paths, file.OldPath)
 			}
-			fmt.Println("✅ Changes pushed successfully!")
 		}
+		related, err := git.RecentMessages(rev, styleHistorySize/2, paths)
+		if err != nil {
+			diag.Warn("main", "failed to read related commit messages", "error", err)
+		}
+		add(related)
+	}
+	recent, err := git.RecentMessages(rev, styleHistorySize, nil)
+	if err != nil {
+		diag.Warn("main", "failed to read recent commit messages", "error", err)
+	}
+	add(recent)
+	diag.Debug("main", "loaded commit history for style", "rev", rev, "messages", len(history), "examples", cfg.StyleExamples)
+	return history, cfg.StyleExamples
+}
+
+// loadDiffOptions combines global ignore_patterns from config with the
+// repository's .gitcommignore, so repo rules can override global ones.
+func loadDiffOptions() git.DiffOptions {
+	cfg, _ := config.LoadRuntimeConfig()
+	patterns := append([]string(nil), cfg.IgnorePatterns...)
+	if root, err := git.RepoRoot(); err == nil {
+		path := filepath.Join(root, ignore.FileName)
+		if data, err := os.ReadFile(path); err == nil {
+			patterns = append(patterns, ignore.ParseLines(string(data))...)
+			diag.Debug("main", "loaded ignore file", "path", path)
+		} else if !os.IsNotExist(err) {
+			diag.Warn("main", "failed to read ignore file", "path", path, "error", err)
+		}
+	}
+	opts := git.DiffOptions{
+		Exclude:          ignore.New(patterns),
+		NoRenames:        cfg.DiffNoRenames,
+		FindCopies:       cfg.DiffFindCopies,
+		IgnoreWhitespace: cfg.DiffIgnoreWhitespace,
+		IgnoreBlankLines: cfg.DiffIgnoreBlankLines,
+		Context:          cfg.DiffContext,
+		FunctionContext:  cfg.DiffFunctionContext,
+		Algorithm:        cfg.DiffAlgorithm,
 	}
+	cliDiffFlags.apply(&opts)
+	context := "default"
+	if opts.Context != nil {
+		context = strconv.Itoa(*opts.Context)
+	}
+	logf("diff options: %d ignore patterns, renames=%v copies=%v w=%v ignore-blank-lines=%v U=%s function-context=%v algorithm=%q",
+		len(patterns), !opts.NoRenames, opts.FindCopies, opts.IgnoreWhitespace, opts.IgnoreBlankLines, context, opts.FunctionContext, opts.Algorithm)
+	return opts
 }
 
 func runSelfUpdate() error {
@@ -210,9 +574,7 @@ func runSetup() error {
 		fmt.Printf("Config file already exists at %s\n", configPath)
 		fmt.Print("Overwrite? (y = overwrite, k = keep, b = backup+overwrite): ")
 
-		var choice string
-		fmt.Scanln(&choice)
-		choice = strings.ToLower(strings.TrimSpace(choice))
+		choice := strings.ToLower(strings.TrimSpace(readLine()))
 
 		switch choice {
 		case "k", "":
@@ -241,9 +603,7 @@ func runSetup() error {
 	useEnvKey := false
 	if envKey != "" {
 		fmt.Printf("Found %s in environment. Use it? (Y/n): ", envName)
-		var choice string
-		fmt.Scanln(&choice)
-		choice = strings.ToLower(strings.TrimSpace(choice))
+		choice := strings.ToLower(strings.TrimSpace(readLine()))
 		if choice == "" || choice == "y" || choice == "yes" {
 			useEnvKey = true
 		}
@@ -256,11 +616,32 @@ func runSetup() error {
 		fmt.Printf("Using API key from %s. It will not be stored in config.\n", envName)
 		logf("setup: openrouter use_env=true")
 	} else {
-		fmt.Print("Enter OpenRouter API key: ")
-		fmt.Scanln(&cfg.OpenRouterAPIKey)
-		logf("setup: openrouter set=%v", cfg.OpenRouterAPIKey != "")
-		if cfg.OpenRouterAPIKey == "" {
-			return fmt.Errorf("OpenRouter API key is required")
+		fmt.Println("How should GitComm get your OpenRouter API key?")
+		fmt.Println("  1) Store the key in the config file (plaintext)")
+		fmt.Println("  2) Run a credential command that prints the key (e.g. `pass show openrouter`)")
+		fmt.Print("Choice (1/2): ")
+		choice := strings.TrimSpace(readLine())
+		switch choice {
+		case "2":
+			fmt.Print("Enter API key command: ")
+			cfg.APIKeyCommand = strings.TrimSpace(readLine())
+			logf("setup: openrouter use_command=%v", cfg.APIKeyCommand != "")
+			if cfg.APIKeyCommand == "" {
+				return fmt.Errorf("API key command is required")
+			}
+			if _, err := config.ResolveAPIKey(config.APIKeyEntry{Command: cfg.APIKeyCommand}); err != nil {
+				return fmt.Errorf("API key command did not return a key: %w", err)
+			}
+			fmt.Println("Credential command returned a key. Only the command will be stored in config.")
+		case "1", "":
+			fmt.Print("Enter OpenRouter API key: ")
+			cfg.OpenRouterAPIKey = strings.TrimSpace(readLine())
+			logf("setup: openrouter set=%v", cfg.OpenRouterAPIKey != "")
+			if cfg.OpenRouterAPIKey == "" {
+				return fmt.Errorf("OpenRouter API key is required")
+			}
+		default:
+			return fmt.Errorf("unrecognized choice %q", choice)
 		}
 	}
 
@@ -337,13 +718,53 @@ func handleSetModel(arg string) {
 func helpText() string {
 	return strings.TrimSpace("\n" +
 		"Usage:\n" +
-		"  gitcomm [flags]\n" +
-		"  gitcomm update\n\n" +
+		"  gitcomm [-C <path>] [flags] [-- <pathspec>...]\n" +
+		"  gitcomm - | -diff-file <path>\n" +
+		"  gitcomm update\n" +
+		"  gitcomm reword [-force] <base>..<head>\n" +
+		"  gitcomm pr [-base <branch>] [-o <file>] [-template <file>] [-json]\n" +
+		"  gitcomm changelog [-version <name>] [-write] [-file <path>] [<from>[..<to>]]\n" +
+		"  gitcomm split [-y] [-restore | -discard]\n" +
+		"  gitcomm hook install|uninstall\n" +
+		"  gitcomm pair add \"Name <email>\"... | remove [-all] <name or email>... | list\n" +
+		"  gitcomm retry [-amend] [-e] [-a] [-push [-remote <name>]] [commit flags] [-- <pathspec>...]\n\n" +
 		"Flags:\n" +
-		"  -setup      Run interactive setup to configure OpenRouter API key and defaults\n" +
+		"  -setup      Run interactive setup to configure OpenRouter API key (or key command) and defaults\n" +
 		"  -sa         Stage all changes before analyzing\n" +
+		"  -a          Also include modified and deleted tracked files, like `git commit -a`\n" +
+		"  -wt         Preview a message for the unstaged work-tree changes; the index is not touched\n" +
+		"  -- <paths>  Analyze and commit only these paths as they are in the work tree,\n" +
+		"              like `git commit -- <paths>`; other staged changes stay staged\n" +
 		"  -auto       Generate a commit message and auto-commit with it\n" +
-		"  -ap         Generate, auto-commit, and push to remote\n" +
+		"  -ap         Generate, auto-commit, and push to remote; sets the upstream for new\n" +
+		"              branches, offers `git pull --rebase` if the push is rejected, and\n" +
+		"              uses --force-with-lease with -amend\n" +
+		"  -remote     Remote to push to with -ap (default: the upstream, then origin)\n" +
+		"  -amend      Regenerate the message for HEAD from HEAD^..index and amend it\n" +
+		"  -e          Review the generated message in git's editor, then commit\n" +
+		"\n" +
+		"  When committing (-auto, -ap, -e, -amend), these are passed to `git commit`:\n" +
+		"  -S          GPG-sign the commit\n" +
+		"  -signoff    Add a Signed-off-by trailer\n" +
+		"  -no-verify  Bypass the pre-commit and commit-msg hooks\n" +
+		"  -author     Override the author, e.g. -author \"Jane Doe <jane@example.com>\"\n" +
+		"  -commit-flag  Any other git commit flag, repeatable, e.g. -commit-flag=--date=now\n" +
+		"\n" +
+		"  Shaping the diff sent to the model (also settable in config.json):\n" +
+		"  -no-renames         Turn off rename detection\n" +
+		"  -find-copies        Detect copied files as well as renames\n" +
+		"  -w                  Ignore whitespace changes\n" +
+		"  -ignore-blank-lines Ignore changes whose lines are all blank\n" +
+		"  -U <n>              Lines of context around each change\n" +
+		"  -function-context   Show the whole function around each change\n" +
+		"  -diff-algorithm     myers, minimal, patience or histogram\n" +
+		"\n" +
+		"  -diff-file  Print only a message for the diff in <path> instead of the staged\n" +
+		"              changes; `gitcomm -` reads it from stdin, e.g. `git diff A B | gitcomm -`.\n" +
+		"              Nothing is committed, so commit, staging and diff flags are refused\n" +
+		"\n" +
+		"  -C <path>   Run as if gitcomm was started in <path>; also works before a command,\n" +
+		"              e.g. gitcomm -C ../service pr\n" +
 		"  -debug      Enable verbose debug logging\n" +
 		"  -set-model  Set model at position (format: position:provider/model-name)\n" +
 		"               Position: 1 = primary, 2 = first fallback, etc.\n" +
@@ -351,12 +772,39 @@ func helpText() string {
 		"               Example: 2:meta-llama/llama-3.3-8b-instruct:free\n\n" +
 		"Commands:\n" +
 		"  update      Install the latest GitComm with `go install github.com/ktappdev/gitcomm@latest`\n" +
-		"              Only works for Go-installed copies of GitComm and requires `go` on PATH\n\n" +
+		"              Only works for Go-installed copies of GitComm and requires `go` on PATH\n" +
+		"  reword      Regenerate messages for each commit in <base>..<head> (must end at HEAD),\n" +
+		"              review old and new side by side, and rewrite the approved ones\n" +
+		"              Refuses merges or pushed commits unless -force is given\n" +
+		"  pr          Generate a pull request title and Markdown description for the current\n" +
+		"              branch against its merge-base with the base branch, following the\n" +
+		"              repository's PR template if present; prints to stdout or -o <file>\n" +
+		"  changelog   Generate release notes (breaking changes, added, fixed, ...) for the\n" +
+		"              commits since the previous tag, or between two refs; -write updates\n" +
+		"              CHANGELOG.md in Keep a Changelog format\n" +
+		"  split       Propose how to split the staged changes into logical commits, let you\n" +
+		"              review and edit the plan, then create the commits one by one;\n" +
+		"              on failure HEAD and the index are restored (-restore after a crash);\n" +
+		"              a commit rejected by a hook can be finished with `gitcomm retry`\n" +
+		"  hook        Install or remove a prepare-commit-msg hook so plain `git commit` opens\n" +
+		"              the editor with a generated message; skips merges, amends and -m,\n" +
+		"              and never blocks a commit if generation fails\n" +
+		"  pair        Manage the co-authors you are pairing with; each active co-author gets a\n" +
+		"              Co-authored-by trailer on generated messages until removed\n" +
+		"  retry       Commit with the message saved when a hook or git rejected the last\n" +
+		"              commit, without generating a new one; -push pushes afterwards\n\n" +
 		"Common examples:\n" +
 		"  gitcomm\n" +
 		"  gitcomm -sa\n" +
 		"  gitcomm -sa -auto\n" +
 		"  gitcomm -sa -ap\n" +
+		"  gitcomm -amend\n" +
+		"  gitcomm -amend -ap\n" +
+		"  gitcomm -a -auto\n" +
+		"  gitcomm -auto -- src/parser docs/syntax.md\n" +
+		"  gitcomm -wt\n" +
+		"  git diff main..feature | gitcomm -\n" +
+		"  gitcomm -sa -e -S -signoff\n" +
 		"  gitcomm update\n")
 }
 
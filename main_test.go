@@ -2,9 +2,13 @@ package main
 
 import (
 	"errors"
+	"flag"
+	"os"
 	"os/exec"
 	"strings"
 	"testing"
+
+	"github.com/ktappdev/gitcomm/internal/git"
 )
 
 func TestRunSelfUpdateSuccess(t *testing.T) {
@@ -82,3 +86,224 @@ func TestHelpTextIncludesUpdateCommand(t *testing.T) {
 		}
 	}
 }
+
+func TestSideBySideWrapsAndAlignsColumns(t *testing.T) {
+	got := sideBySide("Current", "wip", "Generated", "Add a fairly long subject line\n\nBody", 12)
+	want := strings.Join([]string{
+		"Current      │ Generated",
+		"─────────────┼─────────────",
+		"wip          │ Add a fairly",
+		"             │ long subject",
+		"             │ line",
+		"             │ ",
+		"             │ Body",
+	}, "\n")
+	if got != want {
+		t.Fatalf("sideBySide() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrepareCommitMsgSkipsMessagesWithSource(t *testing.T) {
+	path := t.TempDir() + "/COMMIT_EDITMSG"
+	if err := os.WriteFile(path, []byte("Fix bug\n# comment\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range []string{"message", "template", "merge", "squash", "commit"} {
+		if err := prepareCommitMsg([]string{path, source, "HEAD"}); err != nil {
+			t.Fatalf("prepareCommitMsg(%s) error = %v", source, err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "Fix bug\n# comment\n" {
+		t.Fatalf("message file should be untouched, got %q", data)
+	}
+}
+
+func TestHasMessageContentIgnoresComments(t *testing.T) {
+	if hasMessageContent("\n# Please enter the commit message\n#\n", "#") {
+		t.Fatal("comments only should count as empty")
+	}
+	if !hasMessageContent("WIP\n# comment\n", "#") {
+		t.Fatal("expected existing text to count as content")
+	}
+	if !hasMessageContent("# not a comment\n; comment\n", ";") {
+		t.Fatal("custom comment char should be respected")
+	}
+}
+
+func TestHasMessageContentStopsAtScissors(t *testing.T) {
+	// What `git commit -v` (or commit.verbose=true) writes before the hook
+	// runs: the usual comments, then the scissors line and the diff.
+	verbose := strings.Join([]string{
+		"",
+		"# Please enter the commit message for your changes. Lines starting",
+		"# with '#' will be ignored, and an empty message aborts the commit.",
+		"#",
+		"# ------------------------ >8 ------------------------",
+		"# Do not modify or remove the line above.",
+		"# Everything below it will be ignored.",
+		"diff --git a/a.go b/a.go",
+		"index 3b18e51..a9c8f2d 100644",
+		"--- a/a.go",
+		"+++ b/a.go",
+		"@@ -1 +1 @@",
+		"-package a",
+		"+package b",
+		"",
+	}, "\n")
+	if hasMessageContent(verbose, "#") {
+		t.Fatal("the diff below the scissors line should not count as content")
+	}
+	if hasMessageContent(strings.ReplaceAll(verbose, "#", ";"), ";") {
+		t.Fatal("the scissors line should use core.commentChar")
+	}
+	if !hasMessageContent("WIP\n"+verbose, "#") {
+		t.Fatal("text above the scissors line is content")
+	}
+}
+
+func TestRetryCommandRepeatsCommitOptions(t *testing.T) {
+	got := retryCommand(git.CommitOptions{Amend: true, Signoff: true, Author: "Jane <jane@example.com>", Extra: []string{"--date=now"}}, false, "")
+	want := `gitcomm retry -amend -signoff -author "Jane <jane@example.com>" -commit-flag "--date=now"`
+	if got != want {
+		t.Fatalf("retryCommand() = %s\nwant %s", got, want)
+	}
+
+	// -ap keeps its push, and the remote given with -remote.
+	if got, want := retryCommand(git.CommitOptions{}, true, ""), "gitcomm retry -push"; got != want {
+		t.Fatalf("retryCommand() = %s\nwant %s", got, want)
+	}
+	if got, want := retryCommand(git.CommitOptions{Edit: true, NoVerify: true}, true, "upstream"), "gitcomm retry -e -push -remote upstream -no-verify"; got != want {
+		t.Fatalf("retryCommand() = %s\nwant %s", got, want)
+	}
+	if got, want := retryCommand(git.CommitOptions{}, false, "upstream"), "gitcomm retry"; got != want {
+		t.Fatalf("retryCommand() = %s\nwant %s", got, want)
+	}
+}
+
+func TestPathspecsAndScopeChecks(t *testing.T) {
+	if paths, ok := pathspecs([]string{"-auto", "--", "a.go", "pr"}, []string{"a.go", "pr"}); !ok || strings.Join(paths, " ") != "a.go pr" {
+		t.Fatalf("pathspecs() = %q, %v; want the paths after --", paths, ok)
+	}
+	if _, ok := pathspecs([]string{"-auto", "pr"}, []string{"pr"}); ok {
+		t.Fatal("a subcommand must not be taken for a pathspec")
+	}
+	if _, ok := pathspecs([]string{"--"}, nil); !ok {
+		t.Fatal("a trailing -- should be detected")
+	}
+
+	for _, tc := range []struct {
+		scope      git.Scope
+		dashDash   bool
+		stageAll   bool
+		committing bool
+		ok         bool
+	}{
+		{scope: git.Scope{Paths: []string{"a"}}, dashDash: true, committing: true, ok: true},
+		{scope: git.Scope{}, dashDash: true},
+		{scope: git.Scope{All: true}, stageAll: true},
+		{scope: git.Scope{All: true, Paths: []string{"a"}}, dashDash: true},
+		{scope: git.Scope{WorkTree: true}, ok: true},
+		{scope: git.Scope{WorkTree: true}, committing: true},
+	} {
+		if got := checkScope(tc.scope, tc.dashDash, tc.stageAll, tc.committing); (got == "") != tc.ok {
+			t.Fatalf("checkScope(%+v) = %q, want ok=%v", tc, got, tc.ok)
+		}
+	}
+
+	if got := strings.Join(scopeArgs(git.Scope{Paths: []string{"src/a.go", "my file"}}), " "); got != "-- src/a.go 'my file'" {
+		t.Fatalf("scopeArgs() = %s", got)
+	}
+}
+
+func TestDiffFlagsOverrideOnlyWhatWasGiven(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := registerDiffFlags(fs)
+	if err := fs.Parse([]string{"-w=false", "-U", "6", "-diff-algorithm", "Patience"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	opts := git.DiffOptions{IgnoreWhitespace: true, FunctionContext: true}
+	flags.apply(&opts)
+	if opts.Context == nil || *opts.Context != 6 {
+		t.Fatalf("apply() Context = %v, want 6", opts.Context)
+	}
+	opts.Context = nil
+	want := git.DiffOptions{FunctionContext: true, Algorithm: "patience"}
+	if opts != want {
+		t.Fatalf("apply() = %+v, want %+v", opts, want)
+	}
+
+	// -U 0 is a real value, not "unset": it overrides a configured context.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags = registerDiffFlags(fs)
+	if err := fs.Parse([]string{"-U", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	configured := 5
+	opts = git.DiffOptions{Context: &configured}
+	flags.apply(&opts)
+	if opts.Context == nil || *opts.Context != 0 {
+		t.Fatalf("apply() Context = %v, want 0", opts.Context)
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags = registerDiffFlags(fs)
+	opts = git.DiffOptions{}
+	flags.apply(&opts)
+	if opts.Context != nil {
+		t.Fatalf("without -U the context should stay unset, got %d", *opts.Context)
+	}
+
+	*flags.algorithm = "fast"
+	if err := flags.validate(); err == nil {
+		t.Fatal("expected an unknown algorithm to be rejected")
+	}
+}
+
+func TestFindCoAuthorMatchesIdentNameOrEmail(t *testing.T) {
+	coAuthors := []string{"Jane Doe <jane@example.com>", "Bob <bob@example.com>"}
+	for query, want := range map[string]int{
+		"jane doe":                    0,
+		"BOB@example.com":             1,
+		"Robert <bob@example.com>":    1,
+		"Jane Doe <jane@example.com>": 0,
+		"carol@example.com":           -1,
+		"Jane":                        -1,
+	} {
+		if got := findCoAuthor(coAuthors, query); got != want {
+			t.Fatalf("findCoAuthor(%q) = %d, want %d", query, got, want)
+		}
+	}
+}
+
+func TestDiffInputRejectsCommitAndScopeFlags(t *testing.T) {
+	if !readsDiffInput("", []string{"-"}, false) || !readsDiffInput("p.diff", nil, false) {
+		t.Fatal("expected - and -diff-file to read a diff")
+	}
+	if readsDiffInput("", []string{"-"}, true) || readsDiffInput("", []string{"pr"}, false) {
+		t.Fatal("a path named - and subcommands must not read a diff")
+	}
+
+	for _, tc := range []struct {
+		diffFile string
+		args     []string
+		set      []string
+		ok       bool
+	}{
+		{args: []string{"-"}, set: []string{"C", "debug"}, ok: true},
+		{diffFile: "p.diff", set: []string{"diff-file"}, ok: true},
+		{args: []string{"-", "a.go"}},
+		{diffFile: "p.diff", args: []string{"-"}},
+		{args: []string{"-"}, set: []string{"auto"}},
+		{diffFile: "p.diff", set: []string{"diff-file", "S"}},
+		{args: []string{"-"}, set: []string{"w"}},
+	} {
+		if err := checkDiffInput(tc.diffFile, tc.args, false, tc.set); (err == nil) != tc.ok {
+			t.Fatalf("checkDiffInput(%+v) = %v, want ok=%v", tc, err, tc.ok)
+		}
+	}
+	if err := checkDiffInput("p.diff", []string{"a.go"}, true, nil); err == nil {
+		t.Fatal("paths must be rejected with -diff-file")
+	}
+}
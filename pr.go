@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/analyzer"
+	"github.com/ktappdev/gitcomm/internal/config"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+)
+
+// prTemplatePaths are the locations GitHub, GitLab and friends look for a
+// pull request template, relative to the repository root.
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+	".gitlab/merge_request_templates/Default.md",
+}
+
+// runPR describes the current branch against its merge-base with the base
+// branch and writes the title and Markdown description to stdout or a file.
+func runPR(args []string) error {
+	fs := flag.NewFlagSet("pr", flag.ContinueOnError)
+	baseFlag := fs.String("base", "", "Branch the pull request targets (default: pr_base_branch, origin's default branch, main or master)")
+	outFlag := fs.String("o", "", "Write the result to this file instead of stdout")
+	templateFlag := fs.String("template", "", "Pull request template to follow (default: the repository's template, if any)")
+	jsonFlag := fs.Bool("json", false, "Print {\"title\", \"body\"} JSON instead of plain text")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: gitcomm pr [-base <branch>] [-o <file>] [-template <file>] [-json]")
+	}
+
+	// Progress output goes to stderr while the description itself is
+	// printed to stdout, so the result can be piped straight into other
+	// tools.
+	var progress io.Writer = os.Stdout
+	if *outFlag == "" {
+		progress = os.Stderr
+	}
+
+	base, err := resolvePRBase(*baseFlag)
+	if err != nil {
+		return err
+	}
+	branch, err := git.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	mergeBase, err := git.MergeBase(base, "HEAD")
+	if err != nil {
+		return err
+	}
+	head, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	commits, err := git.ListCommits(mergeBase, head)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("%s has no commits that are not already in %s", branch, base)
+	}
+	fmt.Fprintf(progress, "🔀 Describing %s: %d %s since %s\n", branch, len(commits), git.Pluralize(len(commits), "commit", "commits"), base)
+
+	opts := loadDiffOptions()
+	opts.Base = mergeBase
+	opts.Head = head
+	opts.Progress = progress
+	changes, err := repo.GetStagedChanges(opts)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s against %s: %w", branch, base, err)
+	}
+
+	template, templatePath, err := loadPRTemplate(*templateFlag)
+	if err != nil {
+		return err
+	}
+	if templatePath != "" {
+		fmt.Fprintf(progress, "📝 Following template %s\n", templatePath)
+	}
+
+	messages := make([]string, len(commits))
+	for i, commit := range commits {
+		messages[i] = commit.Message
+	}
+	pr, err := analyzer.GeneratePullRequest(analyzer.PRInput{
+		Input:    analyzer.Input{Diff: changes.Diff, Files: changes.Files, Submodules: changes.Submodules, Symbols: changes.Symbols, Progress: progress},
+		Branch:   branch,
+		Base:     base,
+		Commits:  messages,
+		Template: template,
+	})
+	if err != nil {
+		return err
+	}
+
+	output := pr.String() + "\n"
+	if *jsonFlag {
+		data, err := json.MarshalIndent(map[string]string{"title": pr.Title, "body": pr.Body}, "", "  ")
+		if err != nil {
+			return err
+		}
+		output = string(data) + "\n"
+	}
+	if *outFlag == "" {
+		_, err := fmt.Fprint(os.Stdout, output)
+		return err
+	}
+	if err := os.WriteFile(*outFlag, []byte(output), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *outFlag, err)
+	}
+	fmt.Printf("✅ Pull request description written to %s\n", *outFlag)
+	return nil
+}
+
+func resolvePRBase(flagValue string) (string, error) {
+	base := flagValue
+	if base == "" {
+		cfg, _ := config.LoadRuntimeConfig()
+		base = cfg.PRBaseBranch
+	}
+	if base == "" {
+		detected, err := git.DefaultBaseBranch()
+		if err != nil {
+			return "", err
+		}
+		base = detected
+	}
+	if _, err := git.ResolveCommit(base); err != nil {
+		return "", fmt.Errorf("base branch %q not found", base)
+	}
+	diag.Info("main", "pr base", "base", base, "from_flag", flagValue != "")
+	return base, nil
+}
+
+// loadPRTemplate reads the template given with -template, or the first
+// conventional template found in the repository. A missing repository
+// template is not an error.
+func loadPRTemplate(path string) (string, string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read template: %w", err)
+		}
+		return string(data), path, nil
+	}
+	root, err := git.RepoRoot()
+	if err != nil {
+		return "", "", err
+	}
+	for _, candidate := range prTemplatePaths {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(candidate)))
+		if err == nil && strings.TrimSpace(string(data)) != "" {
+			return string(data), candidate, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			diag.Warn("main", "failed to read pr template", "path", candidate, "error", err)
+		}
+	}
+	return "", "", nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+)
+
+// pushChanges pushes the current branch. A branch without an upstream is
+// pushed with -u to remote (or the default push remote), and a push rejected
+// because the remote moved on can be retried after `git pull --rebase`.
+// forceWithLease is used after an amend, which rewrites the pushed commit.
+func pushChanges(remote string, forceWithLease bool) error {
+	branch, err := git.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	if branch == "HEAD" {
+		return fmt.Errorf("HEAD is detached; check out a branch before pushing")
+	}
+
+	opts := git.PushOptions{ForceWithLease: forceWithLease}
+	upstreamRemote, upstreamBranch, hasUpstream := git.Upstream(branch)
+	pullBranch := branch
+	switch {
+	case remote != "" && (!hasUpstream || remote != upstreamRemote):
+		opts.Remote, opts.Branch, opts.SetUpstream = remote, branch, !hasUpstream
+	case hasUpstream:
+		remote, pullBranch = upstreamRemote, upstreamBranch
+	default:
+		if remote, err = choosePushRemote(branch); err != nil {
+			return err
+		}
+		opts.Remote, opts.Branch, opts.SetUpstream = remote, branch, true
+		fmt.Printf("🔗 %s has no upstream yet; pushing with -u %s %s\n", branch, remote, branch)
+	}
+	if forceWithLease {
+		fmt.Println("   HEAD was amended, so pushing with --force-with-lease.")
+	}
+	diag.Info("main", "pushing", "branch", branch, "remote", remote, "set_upstream", opts.SetUpstream, "force_with_lease", forceWithLease)
+
+	err = repo.Push(opts)
+	var pushErr *git.PushError
+	if !errors.As(err, &pushErr) || pushErr.Reason != git.PushNonFastForward {
+		return err
+	}
+
+	fmt.Printf("⚠️  %s/%s has commits that are not in your branch.\n", remote, pullBranch)
+	fmt.Print("Rebase onto them with `git pull --rebase` and push again? (Y/n): ")
+	choice := strings.ToLower(strings.TrimSpace(readLine()))
+	if choice != "" && choice != "y" && choice != "yes" {
+		return err
+	}
+	if err := git.PullRebase(remote, pullBranch); err != nil {
+		return fmt.Errorf("git pull --rebase failed: %w", err)
+	}
+	fmt.Println("🚀 Pushing again...")
+	return repo.Push(opts)
+}
+
+// choosePushRemote picks the remote for a branch without an upstream,
+// asking when several remotes are configured and none is the default.
+func choosePushRemote(branch string) (string, error) {
+	remotes, err := git.Remotes()
+	if err != nil {
+		return "", err
+	}
+	if len(remotes) == 0 {
+		return "", fmt.Errorf("no remote is configured; add one with `git remote add origin <url>`")
+	}
+	if remote := git.DefaultPushRemote(branch, remotes); remote != "" {
+		return remote, nil
+	}
+
+	fmt.Printf("%s has no upstream. Push to which remote?\n", branch)
+	for i, remote := range remotes {
+		fmt.Printf("  %d. %s\n", i+1, remote)
+	}
+	fmt.Print("Remote (number or name): ")
+	choice := strings.TrimSpace(readLine())
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(remotes) {
+		return remotes[n-1], nil
+	}
+	for _, remote := range remotes {
+		if remote == choice {
+			return remote, nil
+		}
+	}
+	return "", fmt.Errorf("no remote chosen; use -remote <name>")
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ktappdev/gitcomm/internal/analyzer"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+	"github.com/ktappdev/gitcomm/internal/trailer"
+)
+
+const rewordColumnWidth = 38
+
+// runReword regenerates messages for every commit in <base>..<head>, asks for
+// approval of each one, and rewrites the approved commits in one pass.
+func runReword(args []string) error {
+	fs := flag.NewFlagSet("reword", flag.ContinueOnError)
+	force := fs.Bool("force", false, "Allow ranges containing merges or published commits")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: gitcomm reword [-force] <base>..<head>")
+	}
+
+	base, head, err := git.ResolveRange(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	currentHead, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	if head != currentHead {
+		return fmt.Errorf("reword only rewrites ranges ending at HEAD; check out the branch first")
+	}
+
+	commits, err := git.ListCommits(base, head)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		fmt.Println("⚠️  No commits in range; nothing to reword.")
+		return nil
+	}
+	if err := checkRewordSafety(base, head, commits, *force); err != nil {
+		return err
+	}
+
+	fmt.Printf("✏️  Rewording %d %s in %s\n", len(commits), git.Pluralize(len(commits), "commit", "commits"), fs.Arg(0))
+	diffOptions := loadDiffOptions()
+	approved := make(map[string]string)
+	for i, commit := range commits {
+		fmt.Printf("\n[%d/%d] %s %s\n", i+1, len(commits), commit.Short(), commit.Subject)
+		changes, err := repo.GetCommitChanges(commit.Hash, diffOptions)
+		if err != nil {
+			return fmt.Errorf("failed to read diff for %s: %w", commit.Short(), err)
+		}
+		if strings.TrimSpace(changes.Diff) == "" {
+			fmt.Println("   Empty commit; keeping the original message.")
+			continue
+		}
+		history, examples := loadStyleHistory(base, changes.Files)
+		message, err := analyzer.AnalyzeChanges(analyzer.Input{Diff: changes.Diff, Files: changes.Files, History: history, StyleExamples: examples, Submodules: changes.Submodules, Symbols: changes.Symbols})
+		if err != nil {
+			diag.Error("main", "reword analysis failed", "commit", commit.Hash, "error", err)
+			fmt.Printf("❌ Could not generate a message for %s: %v\n", commit.Short(), err)
+			fmt.Println("   Keeping the original message.")
+			continue
+		}
+		if message, err = addTickets(message); err != nil {
+			return err
+		}
+		// The commit already exists, so keep its own trailers rather than
+		// adding today's co-authors.
+		message = trailer.Merge(message, trailer.Trailers(commit.Message))
+
+		fmt.Println(sideBySide("Current", commit.Message, "Generated", message, rewordColumnWidth))
+		fmt.Print("Use the generated message? (y = yes, n = keep current, q = stop asking): ")
+		choice := strings.ToLower(strings.TrimSpace(readLine()))
+		if choice == "q" {
+			break
+		}
+		if choice == "y" || choice == "yes" {
+			approved[commit.Hash] = message
+		}
+	}
+
+	if len(approved) == 0 {
+		fmt.Println("\nNo messages approved; history left unchanged.")
+		return nil
+	}
+	newTip, err := git.RewriteMessages(commits, approved)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n✅ Reworded %d %s. New HEAD is %s.\n", len(approved), git.Pluralize(len(approved), "commit", "commits"), git.CommitInfo{Hash: newTip}.Short())
+	fmt.Printf("   The previous tip is saved as ORIG_HEAD (%s); run `git reset --keep ORIG_HEAD` to undo.\n", git.CommitInfo{Hash: head}.Short())
+	return nil
+}
+
+func checkRewordSafety(base, head string, commits []git.CommitInfo, force bool) error {
+	merges := 0
+	for _, commit := range commits {
+		if len(commit.Parents) > 1 {
+			merges++
+		}
+	}
+	unpublished, err := git.UnpublishedCount(base, head)
+	if err != nil {
+		return err
+	}
+	published := len(commits) - unpublished
+	diag.Info("main", "reword range", "commits", len(commits), "merges", merges, "published", published, "force", force)
+
+	if merges > 0 {
+		if !force {
+			return fmt.Errorf("range contains %d merge %s; rerun with -force to reword anyway", merges, git.Pluralize(merges, "commit", "commits"))
+		}
+		fmt.Printf("⚠️  Range contains %d merge %s; their parents are preserved.\n", merges, git.Pluralize(merges, "commit", "commits"))
+	}
+	if published > 0 {
+		if !force {
+			return fmt.Errorf("%d %s in range already pushed to a remote; rerun with -force to rewrite published history", published, git.Pluralize(published, "commit is", "commits are"))
+		}
+		fmt.Printf("⚠️  %d %s already pushed; you will need to force-push afterwards.\n", published, git.Pluralize(published, "commit is", "commits are"))
+	}
+	return nil
+}
+
+// sideBySide renders two messages in labelled columns, wrapping long lines
+// at word boundaries where possible.
+func sideBySide(leftTitle, left, rightTitle, right string, width int) string {
+	leftLines := wrapColumn(left, width)
+	rightLines := wrapColumn(right, width)
+	rows := len(leftLines)
+	if len(rightLines) > rows {
+		rows = len(rightLines)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s │ %s\n", padColumn(leftTitle, width), rightTitle)
+	fmt.Fprintf(&b, "%s─┼─%s\n", strings.Repeat("─", width), strings.Repeat("─", width))
+	for i := 0; i < rows; i++ {
+		l, r := "", ""
+		if i < len(leftLines) {
+			l = leftLines[i]
+		}
+		if i < len(rightLines) {
+			r = rightLines[i]
+		}
+		fmt.Fprintf(&b, "%s │ %s\n", padColumn(l, width), r)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func wrapColumn(text string, width int) []string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		runes := []rune(line)
+		if len(runes) == 0 {
+			out = append(out, "")
+			continue
+		}
+		for len(runes) > width {
+			cut := width
+			for i := width; i > 0; i-- {
+				if runes[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			out = append(out, strings.TrimRight(string(runes[:cut]), " "))
+			runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+		}
+		out = append(out, string(runes))
+	}
+	return out
+}
+
+func padColumn(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+)
+
+// pathspecs returns the arguments left after flag parsing when they follow
+// a "--", i.e. `gitcomm [flags] -- <pathspec>...`. args are the arguments
+// given to the parser and rest what it left.
+func pathspecs(args, rest []string) ([]string, bool) {
+	i := len(args) - len(rest) - 1
+	if i < 0 || args[i] != "--" {
+		return nil, false
+	}
+	return rest, true
+}
+
+// checkScope rejects flag combinations that have no meaning for scope, and
+// returns "" when scope can be used.
+func checkScope(scope git.Scope, dashDash, stageAll, committing bool) string {
+	switch {
+	case dashDash && len(scope.Paths) == 0:
+		return "-- must be followed by at least one path."
+	case stageAll && !scope.Empty():
+		return "-sa stages everything, so it cannot be combined with -a, -wt or paths."
+	case scope.All && len(scope.Paths) > 0:
+		return "-a cannot be combined with paths; list the paths to commit, or use -a alone."
+	case scope.WorkTree && (scope.All || len(scope.Paths) > 0):
+		return "-wt previews every unstaged change, so it cannot be combined with -a or paths."
+	case scope.WorkTree && committing:
+		return "-wt only previews a message; it cannot be combined with -auto, -ap, -e or -amend."
+	}
+	return ""
+}
+
+// noChangesMessage explains that scope selected nothing.
+func noChangesMessage(scope git.Scope) string {
+	switch {
+	case scope.WorkTree:
+		return "⚠️  The work tree has no unstaged changes."
+	case len(scope.Paths) > 0:
+		return "⚠️  The given paths have no changes against HEAD."
+	case scope.All:
+		return "⚠️  There are no staged changes and no modified tracked files."
+	}
+	return "⚠️  No staged changes. Please stage your changes before running gitcomm."
+}
+
+// commitScoped commits message from scoped's temporary index, or from the
+// staged changes when scoped is nil, and then updates the real index.
+func commitScoped(scoped *git.ScopedIndex, message string, opts git.CommitOptions) error {
+	if scoped == nil {
+		return repo.Commit(message, opts)
+	}
+	if err := scoped.Repo.Commit(message, opts); err != nil {
+		return err
+	}
+	if err := scoped.Finish(); err != nil {
+		diag.Warn("main", "failed to update the index after a scoped commit", "error", err)
+		fmt.Printf("⚠️  Committed, but the index could not be updated: %v\n", err)
+	}
+	return nil
+}
+
+// scopeArgs returns the command-line arguments that select scope again.
+func scopeArgs(scope git.Scope) []string {
+	if scope.All {
+		return []string{"-a"}
+	}
+	if len(scope.Paths) == 0 {
+		return nil
+	}
+	args := []string{"--"}
+	for _, path := range scope.Paths {
+		args = append(args, shellArg(path))
+	}
+	return args
+}
+
+// shellArg quotes s when a shell would otherwise split or expand it.
+func shellArg(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'`$\\*?[]#~;&|<>(){}!") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/analyzer"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+	"github.com/ktappdev/gitcomm/internal/gitdiff"
+)
+
+const splitPlanFile = "GITCOMM_SPLIT_PLAN"
+
+// runSplit asks the model to group the staged hunks into logical commits,
+// lets the user review and edit the plan, and then creates the commits by
+// re-staging each group. HEAD and the index are restored if any step fails,
+// except when git rejects a commit: the commits made so far are kept so that
+// `gitcomm retry` can finish the rejected one.
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ContinueOnError)
+	yesFlag := fs.Bool("y", false, "Create the proposed commits without reviewing the plan")
+	restoreFlag := fs.Bool("restore", false, "Restore HEAD and the index saved by an interrupted split")
+	discardFlag := fs.Bool("discard", false, "Forget the state saved by an interrupted split, keeping its commits")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: gitcomm split [-y] [-restore | -discard]")
+	}
+
+	if *restoreFlag && *discardFlag {
+		return fmt.Errorf("-restore and -discard cannot be used together")
+	}
+	if pending := git.PendingSplit(); pending != nil {
+		if *discardFlag {
+			if err := pending.Discard(); err != nil {
+				return err
+			}
+			fmt.Println("✅ Discarded the state saved by the interrupted split.")
+			return nil
+		}
+		if !*restoreFlag {
+			return fmt.Errorf("an earlier split did not finish; run `gitcomm split -restore` to return to the state before it, or `gitcomm split -discard` to keep its commits")
+		}
+		if err := pending.Restore(); err != nil {
+			return err
+		}
+		fmt.Println("✅ Restored HEAD and the index from the interrupted split.")
+		return nil
+	}
+	if *restoreFlag || *discardFlag {
+		fmt.Println("Nothing to restore.")
+		return nil
+	}
+
+	units, err := git.StagedUnits(loadDiffOptions().Exclude)
+	if err != nil {
+		return err
+	}
+	if len(units) == 0 {
+		return fmt.Errorf("no staged changes to split")
+	}
+	if len(units) == 1 {
+		return fmt.Errorf("the staged change is a single hunk; commit it with `gitcomm` instead")
+	}
+	hunks := make([]analyzer.SplitHunk, len(units))
+	ids := make([]int, len(units))
+	for i, unit := range units {
+		hunks[i] = analyzer.SplitHunk{ID: unit.ID, Path: unit.Path(), Diff: unit.Diff(), Label: splitUnitLabel(unit)}
+		ids[i] = unit.ID
+	}
+	fmt.Printf("✂️  Splitting %d %s\n", len(units), git.Pluralize(len(units), "hunk", "hunks"))
+
+	groups, err := analyzer.ProposeSplit(hunks)
+	if err != nil {
+		return err
+	}
+	for i := range groups {
+		if groups[i].Message, err = addTickets(groups[i].Message); err != nil {
+			return err
+		}
+		if groups[i].Message, err = addTrailers(groups[i].Message); err != nil {
+			return err
+		}
+	}
+	plan := analyzer.FormatSplitPlan(groups, hunks)
+
+	backup, err := git.BackupIndex()
+	if err != nil {
+		return err
+	}
+	var patches []string
+	for {
+		var planErr error
+		groups, patches, planErr = checkSplitPlan(plan, units, ids, backup.Index)
+		if planErr == nil {
+			printSplitPlan(groups, units)
+			if *yesFlag {
+				break
+			}
+			fmt.Print("\nCreate these commits? (y = yes, e = edit plan, q = quit): ")
+		} else {
+			fmt.Printf("\n❌ %v\n", planErr)
+			if *yesFlag {
+				backup.Discard()
+				return fmt.Errorf("proposed plan is not usable")
+			}
+			fmt.Print("Edit the plan? (e = edit, q = quit): ")
+		}
+
+		choice := strings.ToLower(strings.TrimSpace(readLine()))
+		if choice == "y" && planErr == nil {
+			break
+		}
+		if choice != "e" {
+			backup.Discard()
+			fmt.Println("Split cancelled; nothing was changed.")
+			return nil
+		}
+		edited, err := editSplitPlan(plan)
+		if err != nil {
+			backup.Discard()
+			return err
+		}
+		if edited == "" {
+			backup.Discard()
+			fmt.Println("Empty plan; split cancelled and nothing was changed.")
+			return nil
+		}
+		plan = edited
+	}
+
+	fmt.Printf("\n💾 Saved the original index; if interrupted, run `gitcomm split -restore`.\n")
+	if err := createSplitCommits(groups, patches); err != nil {
+		if errors.Is(err, errCommitReported) {
+			// HEAD holds the commits made so far and the index the rejected
+			// one, so `gitcomm retry` can finish it; -restore still undoes
+			// the whole split.
+			fmt.Println("   The remaining commits were not created; their changes are still in the work tree.")
+			fmt.Println("   To undo the whole split instead, run `gitcomm split -restore`;")
+			fmt.Println("   once you are done, run `gitcomm split -discard` to forget the saved state.")
+			return err
+		}
+		diag.Error("main", "split failed; restoring", "error", err)
+		fmt.Printf("❌ %v\n", err)
+		if restoreErr := backup.Restore(); restoreErr != nil {
+			return fmt.Errorf("split failed and restoring also failed (%v); run `gitcomm split -restore`", restoreErr)
+		}
+		return fmt.Errorf("split failed; HEAD and the index were restored to their original state")
+	}
+	if err := backup.Discard(); err != nil {
+		diag.Warn("main", "failed to remove split backup refs", "error", err)
+	}
+	fmt.Printf("✅ Created %d %s.\n", len(groups), git.Pluralize(len(groups), "commit", "commits"))
+	return nil
+}
+
+// checkSplitPlan parses and validates a plan and confirms, on a scratch
+// index, that its commits apply and add up to the staged changes.
+func checkSplitPlan(plan string, units []git.SplitUnit, ids []int, wantTree string) ([]analyzer.SplitGroup, []string, error) {
+	groups, err := analyzer.ParseSplitPlan(plan)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := analyzer.ValidateSplitPlan(groups, ids); err != nil {
+		return nil, nil, err
+	}
+	patches := make([]string, len(groups))
+	for i, group := range groups {
+		patches[i] = git.SubsetPatch(units, group.Hunks)
+	}
+	if err := git.CheckSplit(patches, wantTree); err != nil {
+		return nil, nil, err
+	}
+	return groups, patches, nil
+}
+
+func createSplitCommits(groups []analyzer.SplitGroup, patches []string) error {
+	if err := git.ResetIndexToHead(); err != nil {
+		return err
+	}
+	for i, group := range groups {
+		subject, _, _ := strings.Cut(group.Message, "\n")
+		if err := git.ApplyToIndex(patches[i]); err != nil {
+			return fmt.Errorf("failed to stage commit %d (%s): %w", i+1, subject, err)
+		}
+		if err := repo.Commit(group.Message, git.CommitOptions{Quiet: true}); err != nil {
+			var commitErr *git.CommitError
+			if errors.As(err, &commitErr) {
+				fmt.Printf("\n❌ Commit %d of %d (%s) was not created.\n", i+1, len(groups), subject)
+				reportCommitFailure(err, group.Message, git.CommitOptions{}, git.Scope{}, false, "")
+				return errCommitReported
+			}
+			return fmt.Errorf("failed to create commit %d (%s): %w", i+1, subject, err)
+		}
+		hash, err := git.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("   [%d/%d] %s %s\n", i+1, len(groups), git.CommitInfo{Hash: hash}.Short(), subject)
+	}
+	return nil
+}
+
+func printSplitPlan(groups []analyzer.SplitGroup, units []git.SplitUnit) {
+	byID := make(map[int]git.SplitUnit, len(units))
+	for _, unit := range units {
+		byID[unit.ID] = unit
+	}
+	fmt.Printf("\nProposed %d %s:\n", len(groups), git.Pluralize(len(groups), "commit", "commits"))
+	for i, group := range groups {
+		subject, _, _ := strings.Cut(group.Message, "\n")
+		fmt.Printf("\n%d. %s\n", i+1, subject)
+		for _, id := range group.Hunks {
+			fmt.Printf("     %d  %s\n", id, splitUnitLabel(byID[id]))
+		}
+	}
+}
+
+func splitUnitLabel(unit git.SplitUnit) string {
+	if unit.Hunk < 0 {
+		kind := "whole file"
+		switch {
+		case unit.File.Status == gitdiff.StatusAdded:
+			kind = "new file"
+		case unit.File.Status == gitdiff.StatusDeleted:
+			kind = "deleted"
+		case unit.File.Binary:
+			kind = "binary"
+		case unit.File.OldMode != unit.File.NewMode:
+			kind = "mode change"
+		}
+		return fmt.Sprintf("%s (%s, +%d -%d)", unit.Path(), kind, unit.File.Added, unit.File.Removed)
+	}
+	hunk := unit.File.Hunks[unit.Hunk]
+	added, removed := 0, 0
+	for _, line := range hunk.Lines {
+		switch line.Kind {
+		case gitdiff.LineAdded:
+			added++
+		case gitdiff.LineRemoved:
+			removed++
+		}
+	}
+	label := fmt.Sprintf("%s lines %d-%d", unit.Path(), hunk.NewStart, hunk.NewStart+maxInt(hunk.NewLines-1, 0))
+	if hunk.Section != "" {
+		label += " in " + hunk.Section
+	}
+	return fmt.Sprintf("%s (+%d -%d)", label, added, removed)
+}
+
+// editSplitPlan opens the plan in git's editor and returns the edited text,
+// or "" when everything but comments was deleted.
+func editSplitPlan(plan string) (string, error) {
+	path, err := git.GitPath(splitPlanFile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(plan), 0o644); err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+
+	editor, err := git.Editor()
+	if err != nil {
+		return "", err
+	}
+	cmd := execCommand("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return string(data), nil
+		}
+	}
+	return "", nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/config"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+	"github.com/ktappdev/gitcomm/internal/ticket"
+)
+
+// noTicketWarned keeps split and reword from repeating the missing-ticket
+// warning for every message.
+var noTicketWarned bool
+
+// loadTicketRules reads the ticket settings. The repository's git config
+// (gitcomm.ticketPattern, repeatable, and gitcomm.ticketTemplate) takes
+// precedence over config.json, so a team policy can live with the repo.
+func loadTicketRules() (*ticket.Rules, error) {
+	cfg, _ := config.LoadRuntimeConfig()
+	patterns := git.ConfigValues("gitcomm.ticketPattern")
+	if len(patterns) == 0 {
+		patterns = cfg.TicketPatterns
+	}
+	template := git.ConfigValue("gitcomm.ticketTemplate")
+	if template == "" {
+		template = cfg.TicketTemplate
+	}
+	return ticket.Compile(patterns, template)
+}
+
+// addTickets inserts the ticket IDs found in the current branch name into
+// message and checks that every one of them ended up in the result.
+func addTickets(message string) (string, error) {
+	rules, err := loadTicketRules()
+	if err != nil {
+		return message, err
+	}
+	if rules.Empty() {
+		return message, nil
+	}
+	branch, err := git.CurrentBranch()
+	if err != nil || branch == "HEAD" {
+		diag.Debug("main", "no branch to read ticket IDs from", "error", err)
+		return message, nil
+	}
+	ids := rules.Extract(branch)
+	if len(ids) == 0 {
+		if !noTicketWarned {
+			fmt.Fprintf(os.Stderr, "⚠️  No ticket ID found in branch %q.\n", branch)
+			noTicketWarned = true
+		}
+		return message, nil
+	}
+
+	result := rules.Apply(message, ids)
+	if missing := ticket.Missing(result, ids); len(missing) > 0 {
+		return result, fmt.Errorf("ticket %s is missing from the message; check ticket_template", strings.Join(missing, ", "))
+	}
+	diag.Info("main", "added ticket IDs", "branch", branch, "tickets", strings.Join(ids, ","))
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ktappdev/gitcomm/internal/config"
+	"github.com/ktappdev/gitcomm/internal/diag"
+	"github.com/ktappdev/gitcomm/internal/git"
+	"github.com/ktappdev/gitcomm/internal/trailer"
+)
+
+const pairUsage = `usage: gitcomm pair add "Name <email>"... | remove [-all] <name or email>... | list`
+
+// loadTrailers returns the trailers every generated message gets: the
+// configured ones, then Co-authored-by for each active co-author. The
+// repository's gitcomm.trailer values (repeatable) replace config.json's
+// trailers, like the ticket settings.
+func loadTrailers() ([]trailer.Trailer, error) {
+	cfg, _ := config.LoadRuntimeConfig()
+	templates := git.ConfigValues("gitcomm.trailer")
+	if len(templates) == 0 {
+		templates = cfg.Trailers
+	}
+	if len(templates) == 0 && len(cfg.CoAuthors) == 0 {
+		return nil, nil
+	}
+
+	name, email, err := git.CommitterIdent()
+	if err != nil {
+		diag.Warn("main", "failed to read committer identity for trailers", "error", err)
+	}
+	trailers, err := trailer.Compile(templates, name, email)
+	if err != nil {
+		return nil, err
+	}
+	for _, coAuthor := range cfg.CoAuthors {
+		_, coEmail, ok := trailer.ParseIdent(coAuthor)
+		if !ok {
+			diag.Warn("main", "ignoring invalid co-author", "value", coAuthor)
+			continue
+		}
+		// Crediting yourself as a co-author adds nothing.
+		if email != "" && strings.EqualFold(coEmail, email) {
+			continue
+		}
+		trailers = append(trailers, trailer.Trailer{Key: trailer.CoAuthoredBy, Value: coAuthor})
+	}
+	return trailers, nil
+}
+
+// addTrailers merges the configured trailers and co-authors into message,
+// keeping the trailers it already has.
+func addTrailers(message string) (string, error) {
+	trailers, err := loadTrailers()
+	if err != nil || len(trailers) == 0 {
+		return message, err
+	}
+	merged := trailer.Merge(message, trailers)
+	diag.Info("main", "merged trailers", "configured", len(trailers), "added", len(trailer.Trailers(merged))-len(trailer.Trailers(message)))
+	return merged, nil
+}
+
+// runPair manages the co-authors credited on generated commits.
+func runPair(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(pairUsage)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) > 1 {
+			return fmt.Errorf(pairUsage)
+		}
+		printCoAuthors(cfg.CoAuthors)
+		return nil
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf(pairUsage)
+		}
+		for _, arg := range args[1:] {
+			if _, _, ok := trailer.ParseIdent(arg); !ok {
+				return fmt.Errorf("%q is not in \"Name <email>\" form", arg)
+			}
+		}
+		for _, arg := range args[1:] {
+			name, email, _ := trailer.ParseIdent(arg)
+			ident := name + " <" + email + ">"
+			if i := findCoAuthor(cfg.CoAuthors, email); i >= 0 {
+				fmt.Printf("👥 %s is already a co-author.\n", cfg.CoAuthors[i])
+				continue
+			}
+			cfg.CoAuthors = append(cfg.CoAuthors, ident)
+			fmt.Printf("👥 Added %s as a co-author.\n", ident)
+		}
+	case "remove":
+		fs := flag.NewFlagSet("pair remove", flag.ContinueOnError)
+		all := fs.Bool("all", false, "Remove every co-author")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *all == (fs.NArg() > 0) {
+			return fmt.Errorf(pairUsage)
+		}
+		if *all {
+			cfg.CoAuthors = nil
+			fmt.Println("👥 Removed every co-author.")
+			break
+		}
+		for _, arg := range fs.Args() {
+			i := findCoAuthor(cfg.CoAuthors, arg)
+			if i < 0 {
+				return fmt.Errorf("%q is not a co-author; see `gitcomm pair list`", arg)
+			}
+			fmt.Printf("👥 Removed %s.\n", cfg.CoAuthors[i])
+			cfg.CoAuthors = append(cfg.CoAuthors[:i], cfg.CoAuthors[i+1:]...)
+		}
+	default:
+		return fmt.Errorf("unknown pair command %q; %s", args[0], pairUsage)
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return err
+	}
+	diag.Info("main", "updated co-authors", "count", len(cfg.CoAuthors))
+	printCoAuthors(cfg.CoAuthors)
+	return nil
+}
+
+// findCoAuthor returns the index of the co-author whose ident, name or
+// email matches query, ignoring case, or -1.
+func findCoAuthor(coAuthors []string, query string) int {
+	query = strings.TrimSpace(query)
+	if _, email, ok := trailer.ParseIdent(query); ok {
+		query = email
+	}
+	for i, coAuthor := range coAuthors {
+		name, email, _ := trailer.ParseIdent(coAuthor)
+		if strings.EqualFold(query, coAuthor) || strings.EqualFold(query, name) || strings.EqualFold(query, email) {
+			return i
+		}
+	}
+	return -1
+}
+
+func printCoAuthors(coAuthors []string) {
+	if len(coAuthors) == 0 {
+		fmt.Println("No co-authors. Add one with `gitcomm pair add \"Name <email>\"`.")
+		return
+	}
+	fmt.Println("Co-authored-by trailers added to generated commits:")
+	for _, coAuthor := range coAuthors {
+		fmt.Println("   " + coAuthor)
+	}
+}